@@ -0,0 +1,161 @@
+package gotgbot
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultCallbackDataPrefix marks callback data as a token for a value stored in a CallbackDataCache. Once a
+	// cache's middleware is in use, other buttons' callback data mustn't start with it.
+	DefaultCallbackDataPrefix = "~"
+	DefaultExpiredButtonText  = "This button has expired."
+	// DefaultCallbackDataCacheSize is the number of values a cache keeps if it is created without a size.
+	DefaultCallbackDataCacheSize = 1000
+)
+
+// CallbackDataCache stores values for inline keyboard buttons, so that buttons can carry more data than the 64 bytes
+// telegram allows. The button's callback data only holds a short random token, which is swapped for the stored
+// value before handlers see the callback query. Once the cache is full, the least recently used values are dropped.
+//
+// The cache's Middleware must be added to the dispatcher with Dispatcher.Use for values to be resolved. The zero value
+// is an empty cache of DefaultCallbackDataCacheSize values, which uses DefaultCallbackDataPrefix.
+type CallbackDataCache struct {
+	// Prefix is put before each token, so that tokens can be told apart from other callback data; callback data
+	// starting with it is reserved for the cache. If empty, DefaultCallbackDataPrefix is used.
+	Prefix string
+	// ExpiredText is shown to the user, as an alert, when they press a button whose value has been dropped. If empty,
+	// DefaultExpiredButtonText is used.
+	ExpiredText string
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+type callbackDataEntry struct {
+	token string
+	value interface{}
+}
+
+// NewCallbackDataCache creates a cache which keeps up to size values; if size isn't positive,
+// DefaultCallbackDataCacheSize is used.
+func NewCallbackDataCache(size int) *CallbackDataCache {
+	if size <= 0 {
+		size = DefaultCallbackDataCacheSize
+	}
+	return &CallbackDataCache{
+		Prefix:      DefaultCallbackDataPrefix,
+		ExpiredText: DefaultExpiredButtonText,
+		size:        size,
+		entries:     map[string]*list.Element{},
+		order:       list.New(),
+	}
+}
+
+// Data stores the value, and returns the callback data to use for it.
+func (c *CallbackDataCache) Data(value interface{}) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate callback data token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	c.entries[token] = c.order.PushFront(&callbackDataEntry{token: token, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*callbackDataEntry).token)
+	}
+	return c.prefix() + token, nil
+}
+
+// Button returns an inline keyboard button with the given text, which carries the value.
+func (c *CallbackDataCache) Button(text string, value interface{}) (ext.InlineKeyboardButton, error) {
+	data, err := c.Data(value)
+	if err != nil {
+		return ext.InlineKeyboardButton{}, err
+	}
+	return ext.InlineKeyboardButton{Text: text, CallbackData: data}, nil
+}
+
+// Get returns the value stored for the callback data, and marks it as recently used.
+func (c *CallbackDataCache) Get(data string) (interface{}, bool) {
+	if !strings.HasPrefix(data, c.prefix()) {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	e, ok := c.entries[strings.TrimPrefix(data, c.prefix())]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*callbackDataEntry).value, true
+}
+
+// Middleware resolves the values of callback queries for buttons created by the cache. The value is stored in the
+// query's Value; if the value is a string, it also replaces the query's Data, so that CallBack handlers and routers
+// match it as if telegram had sent it.
+// Queries for values which are no longer in the cache are answered with ExpiredText, and not handled any further.
+func (c *CallbackDataCache) Middleware() Middleware {
+	return func(next UpdateFunc) UpdateFunc {
+		return func(u *Update, d Dispatcher) error {
+			if u.CallbackQuery == nil || !strings.HasPrefix(u.CallbackQuery.Data, c.prefix()) {
+				return next(u, d)
+			}
+
+			value, ok := c.Get(u.CallbackQuery.Data)
+			if !ok {
+				if _, err := u.CallbackQuery.AnswerText(c.expiredText(), true); err != nil {
+					logrus.WithError(err).Warning("failed to answer expired callback query")
+				}
+				return EndGroups{}
+			}
+			u.CallbackQuery.Value = value
+			if s, ok := value.(string); ok {
+				u.CallbackQuery.Data = s
+			}
+			return next(u, d)
+		}
+	}
+}
+
+// expiredText returns the cache's ExpiredText, or the default if it is empty.
+func (c *CallbackDataCache) expiredText() string {
+	if c.ExpiredText == "" {
+		return DefaultExpiredButtonText
+	}
+	return c.ExpiredText
+}
+
+// init sets up a cache which wasn't created by NewCallbackDataCache. c.mu must be held.
+func (c *CallbackDataCache) init() {
+	if c.size <= 0 {
+		c.size = DefaultCallbackDataCacheSize
+	}
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.order = list.New()
+	}
+}
+
+// prefix returns the cache's Prefix; an empty prefix would claim all callback data, so the default is used instead.
+func (c *CallbackDataCache) prefix() string {
+	if c.Prefix == "" {
+		return DefaultCallbackDataPrefix
+	}
+	return c.Prefix
+}
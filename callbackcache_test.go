@@ -0,0 +1,149 @@
+package gotgbot_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestCallbackDataCacheDropsLeastRecentlyUsed(t *testing.T) {
+	c := gotgbot.NewCallbackDataCache(2)
+	a, _ := c.Data("a")
+	b, _ := c.Data("b")
+	if _, ok := c.Get(a); !ok {
+		t.Fatal("a wasn't stored")
+	}
+	c.Data("c")
+	if _, ok := c.Get(b); ok {
+		t.Fatal("least recently used value wasn't dropped")
+	}
+	if v, ok := c.Get(a); !ok || v != "a" {
+		t.Fatalf("recently used value was dropped: %v", v)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("data without the prefix was resolved")
+	}
+}
+
+func TestCallbackDataCacheDefaults(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		c := gotgbot.NewCallbackDataCache(size)
+		first, _ := c.Data(0)
+		for i := 1; i < gotgbot.DefaultCallbackDataCacheSize; i++ {
+			c.Data(i)
+		}
+		if _, ok := c.Get(first); !ok {
+			t.Fatalf("size %d: cache kept fewer than %d values", size, gotgbot.DefaultCallbackDataCacheSize)
+		}
+		for i := 0; i < gotgbot.DefaultCallbackDataCacheSize; i++ {
+			c.Data(i)
+		}
+		if _, ok := c.Get(first); ok {
+			t.Fatalf("size %d: cache kept more than %d values", size, gotgbot.DefaultCallbackDataCacheSize)
+		}
+	}
+
+	c := gotgbot.NewCallbackDataCache(1)
+	c.Prefix = ""
+	data, _ := c.Data("a")
+	if !strings.HasPrefix(data, gotgbot.DefaultCallbackDataPrefix) {
+		t.Fatalf("data %q doesn't have the default prefix", data)
+	}
+	if v, ok := c.Get(data); !ok || v != "a" {
+		t.Fatalf("value wasn't stored: %v", v)
+	}
+}
+
+func TestZeroValueCallbackDataCache(t *testing.T) {
+	c := &gotgbot.CallbackDataCache{Prefix: "#"}
+	if _, ok := c.Get("#missing"); ok {
+		t.Fatal("empty cache resolved a value")
+	}
+	data, err := c.Data("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(data, "#") {
+		t.Fatalf("data %q doesn't have the cache's prefix", data)
+	}
+	if v, ok := c.Get(data); !ok || v != "x" {
+		t.Fatalf("value wasn't stored: %v", v)
+	}
+
+	c = &gotgbot.CallbackDataCache{}
+	first, _ := c.Data(0)
+	for i := 0; i < gotgbot.DefaultCallbackDataCacheSize; i++ {
+		c.Data(i)
+	}
+	if _, ok := c.Get(first); ok {
+		t.Fatalf("zero value cache kept more than %d values", gotgbot.DefaultCallbackDataCacheSize)
+	}
+}
+
+func TestCallbackDataCacheMiddleware(t *testing.T) {
+	type payload struct{ N int }
+	srv, u := newTestUpdater(t)
+	cache := gotgbot.NewCallbackDataCache(2)
+	u.Dispatcher.Use(cache.Middleware())
+	got := make(chan interface{}, 3)
+	long, err := handlers.NewCallback("^long", func(b ext.Bot, upd *gotgbot.Update) error {
+		got <- upd.CallbackQuery.Data
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Dispatcher.AddHandler(long)
+	u.Dispatcher.AddHandler(handlers.CallBack{Response: func(b ext.Bot, upd *gotgbot.Update) error {
+		if upd.CallbackQuery.Value == nil {
+			got <- upd.CallbackQuery.Data
+		} else {
+			got <- upd.CallbackQuery.Value
+		}
+		return nil
+	}})
+	u.StartPolling()
+	defer u.Stop()
+
+	text := "long" + strings.Repeat("z", 100)
+	textButton, _ := cache.Button("text", text)
+	valueButton, _ := cache.Button("value", payload{N: 5})
+	if len(textButton.CallbackData) > handlers.MaxCallbackDataLength {
+		t.Fatalf("callback data %q is too long", textButton.CallbackData)
+	}
+	m := srv.SendMessage(srv.PrivateChat(testUser), testUser, "buttons")
+	for _, c := range []struct {
+		data string
+		want interface{}
+	}{
+		{textButton.CallbackData, text},
+		{valueButton.CallbackData, payload{N: 5}},
+		{"plain", "plain"},
+	} {
+		srv.PressButton(testUser, m, c.data)
+		select {
+		case v := <-got:
+			if v != c.want {
+				t.Fatalf("%q: expected %v, got %v", c.data, c.want, v)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%q wasn't handled", c.data)
+		}
+	}
+
+	cache.Data("newer") // drops the text button's value, which is the least recently used.
+	srv.PressButton(testUser, m, textButton.CallbackData)
+	c, ok := srv.WaitForCall("answerCallbackQuery", 2*time.Second)
+	if !ok || c.Params.Get("text") != gotgbot.DefaultExpiredButtonText || c.Params.Get("show_alert") != "true" {
+		t.Fatalf("expired button wasn't answered: %+v", c)
+	}
+	select {
+	case v := <-got:
+		t.Fatalf("expired button was handled: %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,139 @@
+package gotgbot
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+)
+
+// CommandInfo describes a command, for the bot's command list and for help messages.
+type CommandInfo struct {
+	Command     string
+	Description string
+	// Scopes are the scopes the command is listed in; if empty, it is listed everywhere.
+	Scopes []ext.BotCommandScope
+	// Hidden commands are left out of the command list and help messages.
+	Hidden bool
+}
+
+// CommandHandler is implemented by handlers of commands, so that the dispatcher can list them.
+type CommandHandler interface {
+	Handler
+	CommandInfo() CommandInfo
+}
+
+// HandlerContainer is implemented by handlers which pass updates on to other handlers, such as conversations, so that
+// the dispatcher can list their commands too.
+type HandlerContainer interface {
+	Handler
+	// ListedHandlers returns the handlers whose commands should be listed, such as a conversation's entry points.
+	ListedHandlers() []Handler
+}
+
+// Commands returns the commands of all handlers which aren't hidden, including those within HandlerContainers, in
+// the order they were added. If several handlers share a command, only the first is returned.
+func (d Dispatcher) Commands() []CommandInfo {
+	var cmds []CommandInfo
+	seen := map[string]bool{}
+	for _, groupNum := range *d.handlerGroups {
+		cmds = appendCommands(cmds, seen, d.handlers[groupNum])
+	}
+	return cmds
+}
+
+func appendCommands(cmds []CommandInfo, seen map[string]bool, handlers []Handler) []CommandInfo {
+	for _, handler := range handlers {
+		if hc, ok := handler.(HandlerContainer); ok {
+			cmds = appendCommands(cmds, seen, hc.ListedHandlers())
+		}
+		ch, ok := handler.(CommandHandler)
+		if !ok {
+			continue
+		}
+		info := ch.CommandInfo()
+		if info.Hidden || seen[info.Command] {
+			continue
+		}
+		seen[info.Command] = true
+		cmds = append(cmds, info)
+	}
+	return cmds
+}
+
+// SyncCommands sets the bot's command lists to the commands returned by Commands. Each scope used by a command gets
+// its own list, which also holds the commands of broader scopes, as telegram only shows the list of the narrowest
+// scope which has one. Commands without a description are left out, as telegram requires one.
+// Lists set for scopes which are no longer used aren't removed; use Bot.DeleteMyCommands for those.
+func (d Dispatcher) SyncCommands() error {
+	var cmds []CommandInfo
+	for _, c := range d.Commands() {
+		if c.Description != "" {
+			cmds = append(cmds, c)
+		}
+	}
+
+	scopes := []ext.BotCommandScope{{Type: ext.BotCommandScopeDefault}}
+	for _, c := range cmds {
+		for _, s := range c.Scopes {
+			if !containsScope(scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+
+	for i := range scopes {
+		list := []ext.BotCommand{}
+		for _, c := range cmds {
+			if c.listedIn(scopes[i]) {
+				list = append(list, ext.BotCommand{Command: c.Command, Description: c.Description})
+			}
+		}
+		if _, err := d.Bot.SetMyCommands(list, &scopes[i], ""); err != nil {
+			return errors.Wrapf(err, "failed to set commands for scope %s", scopes[i].Type)
+		}
+	}
+	return nil
+}
+
+// listedIn reports whether the command belongs in the command list of the scope.
+func (c CommandInfo) listedIn(scope ext.BotCommandScope) bool {
+	if len(c.Scopes) == 0 {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if scopeIncludes(s, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeIncludes reports whether all users in the narrow scope are also in the broad one. Scopes of a single chat are
+// in AllPrivateChats or AllGroupChats depending on the chat id, as private chats have positive ids, and groups
+// negative ones.
+func scopeIncludes(broad ext.BotCommandScope, narrow ext.BotCommandScope) bool {
+	switch {
+	case broad == narrow, broad.Type == ext.BotCommandScopeDefault:
+		return true
+	case broad.Type == ext.BotCommandScopeAllPrivateChats:
+		return narrow.Type == ext.BotCommandScopeChat && narrow.ChatId > 0
+	case broad.Type == ext.BotCommandScopeAllGroupChats:
+		return narrow.Type == ext.BotCommandScopeAllChatAdministrators ||
+			narrow.Type == ext.BotCommandScopeChatAdministrators || narrow.Type == ext.BotCommandScopeChatMember ||
+			narrow.Type == ext.BotCommandScopeChat && narrow.ChatId < 0
+	case broad.Type == ext.BotCommandScopeAllChatAdministrators:
+		return narrow.Type == ext.BotCommandScopeChatAdministrators
+	case broad.Type == ext.BotCommandScopeChat:
+		return narrow.ChatId == broad.ChatId &&
+			(narrow.Type == ext.BotCommandScopeChatAdministrators || narrow.Type == ext.BotCommandScopeChatMember)
+	}
+	return false
+}
+
+func containsScope(scopes []ext.BotCommandScope, scope ext.BotCommandScope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
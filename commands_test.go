@@ -0,0 +1,126 @@
+package gotgbot_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func describedCommand(command string, scopes ...ext.BotCommandScope) handlers.Command {
+	cmd := handlers.NewCommand(command, func(b ext.Bot, u *gotgbot.Update) error { return nil })
+	cmd.Description = "Does " + command
+	cmd.Scopes = scopes
+	return cmd
+}
+
+func commandNames(cmds []gotgbot.CommandInfo) []string {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Command)
+	}
+	return names
+}
+
+func TestCommandsAreListedOnce(t *testing.T) {
+	_, u := newTestUpdater(t)
+	hidden := describedCommand("secret")
+	hidden.Hidden = true
+	u.Dispatcher.AddHandler(describedCommand("start"))
+	u.Dispatcher.AddHandler(hidden)
+	u.Dispatcher.AddHandlerToGroup(describedCommand("start"), 1)
+	u.Dispatcher.AddHandlerToGroup(describedCommand("stop"), 1)
+	u.Dispatcher.AddHandler(handlers.NewMessage(nil, nil))
+	if names := commandNames(u.Dispatcher.Commands()); !reflect.DeepEqual(names, []string{"start", "stop"}) {
+		t.Fatalf("unexpected commands %q", names)
+	}
+}
+
+func TestCommandsIncludeConversationEntryPoints(t *testing.T) {
+	_, u := newTestUpdater(t)
+	inner := handlers.NewConversation("inner", []gotgbot.Handler{describedCommand("deep")}, nil, nil)
+	outer := handlers.NewConversation("outer",
+		[]gotgbot.Handler{describedCommand("survey"), inner},
+		map[string][]gotgbot.Handler{"asked": {describedCommand("answer")}},
+		[]gotgbot.Handler{describedCommand("cancel")},
+	)
+	u.Dispatcher.AddHandler(describedCommand("start"))
+	u.Dispatcher.AddHandler(outer)
+	if names := commandNames(u.Dispatcher.Commands()); !reflect.DeepEqual(names, []string{"start", "survey", "deep"}) {
+		t.Fatalf("unexpected commands %q", names)
+	}
+}
+
+func TestSyncCommandsScopes(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	group := ext.BotCommandScope{Type: ext.BotCommandScopeChat, ChatId: -5}
+	private := ext.BotCommandScope{Type: ext.BotCommandScopeChat, ChatId: 42}
+	groupAdmins := ext.BotCommandScope{Type: ext.BotCommandScopeChatAdministrators, ChatId: -5}
+	u.Dispatcher.AddHandler(describedCommand("start"))
+	u.Dispatcher.AddHandler(describedCommand("settings", ext.BotCommandScope{Type: ext.BotCommandScopeAllPrivateChats}))
+	u.Dispatcher.AddHandler(describedCommand("mod", ext.BotCommandScope{Type: ext.BotCommandScopeAllGroupChats}))
+	u.Dispatcher.AddHandler(describedCommand("ban", ext.BotCommandScope{Type: ext.BotCommandScopeAllChatAdministrators}))
+	u.Dispatcher.AddHandler(describedCommand("here", group))
+	u.Dispatcher.AddHandler(describedCommand("mine", private))
+	u.Dispatcher.AddHandler(describedCommand("kick", groupAdmins))
+	undescribed := describedCommand("quiet")
+	undescribed.Description = ""
+	u.Dispatcher.AddHandler(undescribed)
+	if err := u.Dispatcher.SyncCommands(); err != nil {
+		t.Fatal(err)
+	}
+
+	lists := map[string][]string{}
+	for _, c := range srv.CallsTo("setMyCommands") {
+		var cmds []ext.BotCommand
+		if err := json.Unmarshal([]byte(c.Params.Get("commands")), &cmds); err != nil {
+			t.Fatal(err)
+		}
+		var scope ext.BotCommandScope
+		if err := json.Unmarshal([]byte(c.Params.Get("scope")), &scope); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, cmd := range cmds {
+			names = append(names, cmd.Command)
+		}
+		lists[fmt.Sprint(scope)] = names
+	}
+	want := map[ext.BotCommandScope][]string{
+		{Type: ext.BotCommandScopeDefault}:               {"start"},
+		{Type: ext.BotCommandScopeAllPrivateChats}:       {"start", "settings"},
+		{Type: ext.BotCommandScopeAllGroupChats}:         {"start", "mod"},
+		{Type: ext.BotCommandScopeAllChatAdministrators}: {"start", "mod", "ban"},
+		group:       {"start", "mod", "here"},
+		private:     {"start", "settings", "mine"},
+		groupAdmins: {"start", "mod", "ban", "here", "kick"},
+	}
+	if len(lists) != len(want) {
+		t.Fatalf("expected %d lists, got %v", len(want), lists)
+	}
+	for scope, names := range want {
+		if got := lists[fmt.Sprint(scope)]; !reflect.DeepEqual(got, names) {
+			t.Errorf("%v: expected %q, got %q", scope, names, got)
+		}
+	}
+}
+
+func TestSyncCommandsOnStart(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	u.Dispatcher.SyncCommandsOnStart = true
+	u.Dispatcher.AddHandler(describedCommand("start"))
+	u.StartPolling()
+	defer u.Stop()
+	c, ok := srv.WaitForCall("setMyCommands", 2*time.Second)
+	if !ok {
+		t.Fatal("commands weren't synced")
+	}
+	if cmds := c.Params.Get("commands"); cmds != `[{"command":"start","description":"Does start"}]` {
+		t.Fatalf("unexpected commands %s", cmds)
+	}
+}
@@ -1,49 +1,195 @@
 package gotgbot
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"runtime/debug"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 type RawUpdate json.RawMessage
 
 type Dispatcher struct {
-	Bot           ext.Bot
-	MaxRoutines   int
+	Bot         ext.Bot
+	MaxRoutines int
+	// Ordering determines whether updates from the same chat or user may be handled at the same time.
+	Ordering Ordering
+	// QueueSize is the number of updates which can be queued for each chat or user when Ordering is not
+	// Concurrent. Once a queue is full, the dispatcher stops receiving new updates until there is space again.
+	QueueSize int
+	// UpdateTimeout is the deadline set on the context of each update; zero means no deadline.
+	UpdateTimeout time.Duration
+	// FlushInterval is how often data is written to the Persistence while running; zero only flushes on shutdown.
+	FlushInterval time.Duration
+	// ErrorHandler is called with any error returned by a handler or middleware, other than EndGroups and
+	// ContinueGroups. If nil, errors are logged.
+	ErrorHandler func(u *Update, err error)
+	// PanicHandler is called when a handler or middleware panics, with the recovered value and the stack trace.
+	// If nil, the panic is logged.
+	PanicHandler func(u *Update, r interface{}, stack []byte)
+	// StopOnError stops an update from reaching later handler groups once a handler has returned an error.
+	StopOnError bool
+	// ContinueOnPanic lets an update reach later handler groups after a handler has panicked.
+	ContinueOnPanic bool
+	// AutoAnswerCallbacks answers callback queries which no handler has answered, once the update has been handled,
+	// so that the user's client stops waiting for an answer.
+	AutoAnswerCallbacks bool
+	// SyncCommandsOnStart sets the bot's command lists to the dispatcher's commands when it starts; see SyncCommands.
+	SyncCommandsOnStart bool
+
 	updates       chan *RawUpdate
 	handlers      map[int][]Handler
 	handlerGroups *[]int
+	middleware    *middlewares
+	inFlight      *sync.WaitGroup
+	data          *dataStore
+	ctx           context.Context
+	cancel        context.CancelFunc
 }
 
-const DefaultMaxDispatcherRoutines = 50
+const (
+	DefaultMaxDispatcherRoutines = 50
+	DefaultFlushInterval         = time.Minute
+	DefaultQueueSize             = 100
+)
 
 func NewDispatcher(bot ext.Bot, updates chan *RawUpdate) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Dispatcher{
 		Bot:           bot,
 		MaxRoutines:   DefaultMaxDispatcherRoutines,
+		FlushInterval: DefaultFlushInterval,
+		QueueSize:     DefaultQueueSize,
 		updates:       updates,
 		handlers:      map[int][]Handler{},
 		handlerGroups: &[]int{},
+		middleware:    &middlewares{groups: map[int][]Middleware{}},
+		inFlight:      &sync.WaitGroup{},
+		data:          newDataStore(),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
+// Start handles incoming updates until the updates channel is closed. It then waits for all in-flight updates to
+// finish being handled, and flushes the persistence, before returning.
+// No more than MaxRoutines updates are handled at once.
 func (d Dispatcher) Start() {
+	stopFlushing := d.flushPeriodically()
+	defer stopFlushing()
+
+	if d.SyncCommandsOnStart {
+		if err := d.SyncCommands(); err != nil {
+			logrus.WithError(err).Error("failed to sync commands")
+		}
+	}
+
 	limiter := make(chan struct{}, d.MaxRoutines)
+	queues := newKeyedQueues(d.QueueSize, func(update *Update) {
+		defer d.inFlight.Done()
+		d.acquire(limiter)
+		d.processUpdate(update)
+		<-limiter
+	})
+
 	for upd := range d.updates {
-		select {
-		case limiter <- struct{}{}:
-		default:
-			logrus.Debugf("update dispatcher has reached limit of %d", d.MaxRoutines)
-			limiter <- struct{}{} // make sure to send anyway
+		d.inFlight.Add(1)
+		var update *Update
+		if d.Ordering != Concurrent {
+			update = initUpdate(*upd, d.Bot)
+			if key, ok := d.Ordering.key(update); ok {
+				queues.push(key, update)
+				continue
+			}
 		}
-		go func(upd *RawUpdate) {
-			d.processUpdate(upd)
+
+		d.acquire(limiter)
+		go func(upd *RawUpdate, update *Update) {
+			defer d.inFlight.Done()
+			if update == nil {
+				update = initUpdate(*upd, d.Bot)
+			}
+			d.processUpdate(update)
 			<-limiter
-		}(upd)
+		}(upd, update)
+	}
+	d.inFlight.Wait()
+}
+
+// acquire takes a slot from the limiter, blocking until one is free.
+func (d Dispatcher) acquire(limiter chan struct{}) {
+	select {
+	case limiter <- struct{}{}:
+	default:
+		logrus.Debugf("update dispatcher has reached limit of %d", d.MaxRoutines)
+		limiter <- struct{}{} // make sure to send anyway
+	}
+}
+
+// SetPersistence loads all user, chat and bot data from p, and stores any changes to it from then on. It should be
+// called before the dispatcher is started.
+func (d Dispatcher) SetPersistence(p Persistence) error {
+	if err := d.data.load(p); err != nil {
+		return err
+	}
+	for _, groupNum := range *d.handlerGroups {
+		for _, handler := range d.handlers[groupNum] {
+			if ph, ok := handler.(PersistentHandler); ok {
+				if err := ph.SetPersistence(p); err != nil {
+					return errors.Wrapf(err, "failed to set persistence for handler %s", handler.GetName())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Persistence returns the dispatcher's persistence, or nil if none was set.
+func (d Dispatcher) Persistence() Persistence {
+	return d.data.getPersistence()
+}
+
+// FlushPersistence writes all changed data to the persistence.
+func (d Dispatcher) FlushPersistence() error {
+	return d.data.flush()
+}
+
+// flushPeriodically flushes the persistence every FlushInterval. The returned func stops it, and does a final flush.
+func (d Dispatcher) flushPeriodically() func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if d.FlushInterval <= 0 {
+			<-stop
+			return
+		}
+		ticker := time.NewTicker(d.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.FlushPersistence(); err != nil {
+					logrus.WithError(err).Error("failed to flush persistence")
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		if err := d.FlushPersistence(); err != nil {
+			logrus.WithError(err).Error("failed to flush persistence")
+		}
 	}
 }
 
@@ -53,36 +199,131 @@ type ContinueGroups struct{}
 func (eg EndGroups) Error() string      { return "Group iteration ended" }
 func (eg ContinueGroups) Error() string { return "Group iteration has continued" }
 
-func (d Dispatcher) processUpdate(upd *RawUpdate) {
+// handlerPanic is returned in place of the error of a handler which panicked, after the panic has been handled.
+type handlerPanic struct {
+	value interface{}
+}
+
+func (hp handlerPanic) Error() string { return fmt.Sprintf("handler panicked: %v", hp.value) }
+
+func (d Dispatcher) processUpdate(update *Update) {
 	defer func() {
 		if r := recover(); r != nil {
-			logrus.Error(r)
-			debug.PrintStack()
+			d.handlePanic(update, r)
 		}
 	}()
 
-	update := initUpdate(*upd, d.Bot)
+	// the context isn't bound to the bot given to handlers, as the bot and the update's messages are often used after
+	// the handler has returned; handlers pass it to the calls which should be aborted with the update instead.
+	ctx, cancel := d.updateContext()
+	defer cancel()
 
+	update.setBot(d.Bot)
+	update.ctx = ctx
+	if update.CallbackQuery != nil {
+		defer update.CallbackQuery.TrackAnswers()()
+	}
+	d.data.attach(update)
+	defer d.data.release(update)
+
+	err := chain(d.middleware.global, processGroups)(update, d)
+	switch err.(type) {
+	case nil, EndGroups, ContinueGroups, handlerPanic:
+	default:
+		d.handleError(update, err)
+	}
+
+	if d.AutoAnswerCallbacks && update.CallbackQuery != nil {
+		if err := update.CallbackQuery.EnsureAnswered(); err != nil {
+			d.handleError(update, errors.Wrap(err, "failed to answer callback query"))
+		}
+	}
+}
+
+// processGroups passes the update to each handler group in turn, until one of them ends group iteration.
+func processGroups(u *Update, d Dispatcher) error {
 	for _, groupNum := range *d.handlerGroups {
+		err := chain(d.middleware.groups[groupNum], processGroup(groupNum))(u, d)
+		switch err.(type) {
+		case nil, ContinueGroups:
+		case EndGroups:
+			return err
+		case handlerPanic:
+			if !d.ContinueOnPanic {
+				return EndGroups{}
+			}
+		default:
+			d.handleError(u, err)
+			if d.StopOnError {
+				return EndGroups{}
+			}
+		}
+	}
+	return nil
+}
+
+// processGroup returns an UpdateFunc which passes the update to the first handler of the group which accepts it.
+func processGroup(groupNum int) UpdateFunc {
+	return func(u *Update, d Dispatcher) error {
 		for _, handler := range d.handlers[groupNum] {
-			if res, err := handler.CheckUpdate(update); res {
-				err := handler.HandleUpdate(update, d)
-				if err != nil {
-					switch err.(type) {
-					case EndGroups:
-						return
-					case ContinueGroups:
-						continue
-					default:
-						logrus.Warning(err.Error())
-					}
+			if res, err := d.runHandler(handler, u); res {
+				if _, ok := err.(ContinueGroups); ok {
+					continue
 				}
-				break // move to next group
+				return err // move to next group
 			} else if err != nil {
-				logrus.WithError(err).Error("Failed to parse update")
+				d.handleError(u, errors.Wrapf(err, "failed to check update for handler %s", handler.GetName()))
 			}
 		}
+		return nil
+	}
+}
+
+// runHandler checks whether the handler accepts the update, and handles it if so. Panics are passed to the
+// PanicHandler, and returned as a handlerPanic.
+func (d Dispatcher) runHandler(handler Handler, u *Update) (res bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.handlePanic(u, r)
+			res, err = true, handlerPanic{value: r}
+		}
+	}()
+
+	res, err = handler.CheckUpdate(u)
+	if !res {
+		return false, err
+	}
+	return true, handler.HandleUpdate(u, d)
+}
+
+func (d Dispatcher) handleError(u *Update, err error) {
+	if d.ErrorHandler != nil {
+		d.ErrorHandler(u, err)
+		return
+	}
+	logrus.Warning(err.Error())
+}
+
+func (d Dispatcher) handlePanic(u *Update, r interface{}) {
+	stack := debug.Stack()
+	if d.PanicHandler != nil {
+		d.PanicHandler(u, r, stack)
+		return
 	}
+	logrus.WithField("stack", string(stack)).Error(r)
+}
+
+// updateContext derives the context for a single update from the dispatcher's context.
+func (d Dispatcher) updateContext() (context.Context, context.CancelFunc) {
+	if d.UpdateTimeout > 0 {
+		return context.WithTimeout(d.ctx, d.UpdateTimeout)
+	}
+	return context.WithCancel(d.ctx)
+}
+
+// cancelInFlight cancels the contexts of all updates currently being handled.
+func (d Dispatcher) cancelInFlight() {
+	d.cancel()
 }
 
 func (d Dispatcher) AddHandler(handler Handler) {
@@ -97,4 +338,12 @@ func (d Dispatcher) AddHandlerToGroup(handler Handler, group int) {
 		sort.Ints(*d.handlerGroups)
 	}
 	d.handlers[group] = append(currHandlers, handler)
+
+	if p := d.Persistence(); p != nil {
+		if ph, ok := handler.(PersistentHandler); ok {
+			if err := ph.SetPersistence(p); err != nil {
+				logrus.WithError(err).Errorf("failed to set persistence for handler %s", handler.GetName())
+			}
+		}
+	}
 }
@@ -0,0 +1,217 @@
+package gotgbot_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+func TestUpdateContextHasDeadline(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	u.Dispatcher.UpdateTimeout = 50 * time.Millisecond
+	errs := make(chan error, 1)
+	u.Dispatcher.AddHandler(handlers.NewCommand("wait", func(b ext.Bot, upd *gotgbot.Update) error {
+		if _, ok := upd.Context().Deadline(); !ok {
+			t.Error("update context has no deadline")
+		}
+		<-upd.Context().Done()
+		// calls made explicitly under the update's context are aborted with it.
+		_, err := upd.EffectiveMessage.Bot.WithContext(upd.Context()).SendMessage(upd.EffectiveChat.Id, "too late")
+		errs <- err
+		return nil
+	}))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/wait")
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("expected the call to fail once the update's context was done")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("update context was never cancelled")
+	}
+}
+
+func TestMessagesOutliveTheirUpdate(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	msgs := make(chan *ext.Message, 2)
+	u.Dispatcher.AddHandler(handlers.NewCommand("later", func(b ext.Bot, upd *gotgbot.Update) error {
+		sent, err := upd.EffectiveMessage.Bot.NewSendableMessage(upd.EffectiveChat.Id, "working").SendCtx(upd.Context())
+		if err != nil {
+			return err
+		}
+		msgs <- upd.EffectiveMessage
+		msgs <- sent
+		return nil
+	}))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/later")
+	received, sent := <-msgs, <-msgs
+	time.Sleep(50 * time.Millisecond) // the handler has returned, and its context has been cancelled
+	if _, err := received.ReplyText("done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sent.Bot.EditMessageText(sent.Chat.Id, sent.MessageId, "done"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendCtxIsAbortedByItsContext(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	chat := srv.PrivateChat(testUser)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := u.Bot.NewSendableMessage(chat.Id, "hi").SendCtx(ctx); err == nil {
+		t.Fatal("expected a cancelled context to abort the call")
+	}
+	if _, err := u.Bot.NewSendableMessage(chat.Id, "hi").SendCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func panickingHandler() handlers.Message {
+	return handlers.NewMessage(Filters.Text, func(b ext.Bot, u *gotgbot.Update) error {
+		panic("boom")
+	})
+}
+
+func TestErrorHandler(t *testing.T) {
+	for _, stop := range []bool{false, true} {
+		srv, u := newTestUpdater(t)
+		processed := waitForProcessing(u.Dispatcher)
+		r := &recorder{}
+		u.Dispatcher.StopOnError = stop
+		u.Dispatcher.ErrorHandler = func(upd *gotgbot.Update, err error) {
+			r.add("error: " + err.Error())
+		}
+		u.Dispatcher.AddHandler(recordingHandler(r, "handler 0", errors.New("bad")))
+		u.Dispatcher.AddHandlerToGroup(recordingHandler(r, "handler 1", nil), 1)
+		u.StartPolling()
+
+		srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+		waitFor(t, processed)
+		if stop {
+			r.expect(t, "handler 0", "error: bad")
+		} else {
+			r.expect(t, "handler 0", "error: bad", "handler 1")
+		}
+		u.Stop()
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	for _, cont := range []bool{false, true} {
+		srv, u := newTestUpdater(t)
+		processed := waitForProcessing(u.Dispatcher)
+		r := &recorder{}
+		u.Dispatcher.ContinueOnPanic = cont
+		u.Dispatcher.PanicHandler = func(upd *gotgbot.Update, p interface{}, stack []byte) {
+			if !strings.Contains(string(stack), "panickingHandler") {
+				t.Errorf("stack doesn't point at the handler:\n%s", stack)
+			}
+			r.add("panic: " + p.(string))
+			if _, err := upd.EffectiveMessage.ReplyText("something went wrong"); err != nil {
+				t.Error(err)
+			}
+		}
+		u.Dispatcher.ErrorHandler = func(upd *gotgbot.Update, err error) {
+			r.add("error: " + err.Error())
+		}
+		u.Dispatcher.AddHandler(panickingHandler())
+		u.Dispatcher.AddHandlerToGroup(recordingHandler(r, "handler 1", nil), 1)
+		u.StartPolling()
+
+		srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+		waitFor(t, processed)
+		if cont {
+			r.expect(t, "panic: boom", "handler 1")
+		} else {
+			r.expect(t, "panic: boom")
+		}
+		if len(srv.CallsTo("sendMessage")) != 1 {
+			t.Fatal("panic handler couldn't reply")
+		}
+		u.Stop()
+	}
+}
+
+func TestMiddlewarePanicsAreRecovered(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	panicked := make(chan interface{}, 1)
+	u.Dispatcher.PanicHandler = func(upd *gotgbot.Update, p interface{}, stack []byte) {
+		panicked <- p
+	}
+	u.Dispatcher.Use(func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			panic("middleware")
+		}
+	})
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	select {
+	case p := <-panicked:
+		if p != "middleware" {
+			t.Fatalf("unexpected panic %v", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("panic handler wasn't called")
+	}
+	// the dispatcher keeps going.
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "again")
+	select {
+	case <-panicked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("dispatcher stopped after a panic")
+	}
+}
+
+func TestAutoAnswerCallbacks(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	r := &recorder{}
+	u.Dispatcher.AutoAnswerCallbacks = true
+	u.Dispatcher.ErrorHandler = func(upd *gotgbot.Update, err error) {
+		r.add("error: " + err.Error())
+	}
+	h, err := handlers.NewCallback("^by-id$", func(b ext.Bot, upd *gotgbot.Update) error {
+		_, err := b.AnswerCallbackQueryText(upd.CallbackQuery.Id, "done", false)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Dispatcher.AddHandler(h)
+	u.StartPolling()
+
+	m := srv.SendMessage(srv.PrivateChat(testUser), testUser, "buttons")
+	unhandled := srv.PressButton(testUser, m, "unhandled")
+	byId := srv.PressButton(testUser, m, "by-id")
+	time.Sleep(50 * time.Millisecond)
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	answers := map[string][]string{}
+	for _, c := range srv.CallsTo("answerCallbackQuery") {
+		id := c.Params.Get("callback_query_id")
+		answers[id] = append(answers[id], c.Params.Get("text"))
+	}
+	if len(answers[unhandled.Id]) != 1 {
+		t.Fatalf("unhandled query wasn't answered: %q", answers)
+	}
+	if len(answers[byId.Id]) != 1 || answers[byId.Id][0] != "done" {
+		t.Fatalf("query answered by id was answered again: %q", answers)
+	}
+	r.expect(t)
+}
@@ -1,12 +1,14 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
-	"github.com/sirupsen/logrus"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 type Bot struct {
@@ -15,8 +17,56 @@ type Bot struct {
 	FirstName string
 	UserName  string
 	Logger    *logrus.Logger
+	// Requester executes all of the bot's API calls. If nil, DefaultTgBotGetter is used.
+	Requester TgBotGetterInterface
+
+	ctx context.Context
+}
+
+func (b Bot) requester() TgBotGetterInterface {
+	if b.Requester != nil {
+		return b.Requester
+	}
+	return &DefaultTgBotGetter
 }
 
+// WithContext returns a copy of the bot whose API calls are all bound to ctx; cancelling ctx aborts any in-flight
+// request. Every Bot method is context aware this way, eg: b.WithContext(ctx).SendMessage(chatId, text).
+func (b Bot) WithContext(ctx context.Context) Bot {
+	if ctx == nil {
+		panic("nil context")
+	}
+	b.ctx = ctx
+	return b
+}
+
+// Context returns the bot's context; context.Background() if none was set.
+func (b Bot) Context() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
+// detached returns a copy of the bot whose context keeps the values of the bot's context, but is never cancelled.
+// Objects returned by API calls are given this copy, as they often outlive the context the call was made under.
+func (b Bot) detached() Bot {
+	if b.ctx != nil {
+		b.ctx = detachedContext{parent: b.ctx}
+	}
+	return b
+}
+
+// detachedContext has the values of its parent, but not its deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
 func (b Bot) GetMe() (*User, error) {
 	v := url.Values{}
 
@@ -25,7 +75,7 @@ func (b Bot) GetMe() (*User, error) {
 		return nil, errors.Wrapf(err, "could not getMe")
 	}
 	if !r.Ok {
-		return nil, errors.New("invalid getMe request")
+		return nil, NewTelegramError("getMe", r)
 	}
 
 	var u User
@@ -41,7 +91,7 @@ func (b Bot) GetUserProfilePhotos(userId int) (*UserProfilePhotos, error) {
 		return nil, errors.Wrapf(err, "could not get user profile photos")
 	}
 	if !r.Ok {
-		return nil, errors.New("invalid getUserProfilePhotos request")
+		return nil, NewTelegramError("getUserProfilePhotos", r)
 	}
 
 	var userProfilePhotos UserProfilePhotos
@@ -57,7 +107,7 @@ func (b Bot) GetFile(fileId string) (*File, error) {
 		return nil, errors.Wrapf(err, "could not complete getFile request")
 	}
 	if !r.Ok {
-		return nil, errors.New("invalid getFile request")
+		return nil, NewTelegramError("getFile", r)
 	}
 
 	var f File
@@ -68,16 +118,16 @@ func (b Bot) AnswerCallbackQuery(callbackQueryId string) (bool, error) {
 	v := url.Values{}
 	v.Add("callback_query_id", callbackQueryId)
 
-	return b.boolSender("answerCallbackQuery", v)
+	return b.answerCallbackQuery(v)
 }
 
 func (b Bot) AnswerCallbackQueryText(callbackQueryId string, text string, alert bool) (bool, error) {
 	v := url.Values{}
 	v.Add("callback_query_id", callbackQueryId)
 	v.Add("text", text)
-	v.Add("alert", strconv.FormatBool(alert))
+	v.Add("show_alert", strconv.FormatBool(alert))
 
-	return b.boolSender("answerCallbackQuery", v)
+	return b.answerCallbackQuery(v)
 }
 
 func (b Bot) AnswerCallbackQueryURL(callbackQueryId string, URL string) (bool, error) {
@@ -85,5 +135,14 @@ func (b Bot) AnswerCallbackQueryURL(callbackQueryId string, URL string) (bool, e
 	v.Add("callback_query_id", callbackQueryId)
 	v.Add("url", URL)
 
-	return b.boolSender("answerCallbackQuery", v)
+	return b.answerCallbackQuery(v)
+}
+
+// answerCallbackQuery sends the answer, and marks the query as answered if it is being tracked.
+func (b Bot) answerCallbackQuery(v url.Values) (bool, error) {
+	ok, err := b.boolSender("answerCallbackQuery", v)
+	if err == nil {
+		markAnswered(b.Token, v.Get("callback_query_id"))
+	}
+	return ok, err
 }
@@ -0,0 +1,37 @@
+package ext
+
+import (
+	"context"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestDetachedBotKeepsValuesButNotCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	cancel()
+
+	b := Bot{}.WithContext(ctx).detached()
+	if err := b.Context().Err(); err != nil {
+		t.Fatalf("detached context is cancelled: %v", err)
+	}
+	if b.Context().Done() != nil {
+		t.Fatal("detached context can be cancelled")
+	}
+	if v := b.Context().Value(ctxKey{}); v != "value" {
+		t.Fatalf("detached context lost its values, got %v", v)
+	}
+	if (Bot{}).detached().ctx != nil {
+		t.Fatal("a bot without a context got one")
+	}
+}
+
+func TestBotWithoutRequesterUsesDefault(t *testing.T) {
+	if (Bot{}).requester() != &DefaultTgBotGetter {
+		t.Fatal("expected the default getter")
+	}
+	r := &TgBotGetter{}
+	if (Bot{Requester: r}).requester() != r {
+		t.Fatal("expected the bot's own requester")
+	}
+}
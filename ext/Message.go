@@ -92,7 +92,29 @@ type Venue struct {
 	FoursquareId string   `json:"foursquare_id"`
 }
 
+type Dice struct {
+	Emoji string `json:"emoji"`
+	Value int    `json:"value"`
+}
+
+type PollOption struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
+}
+
+type Poll struct {
+	Id                    string       `json:"id"`
+	Question              string       `json:"question"`
+	Options               []PollOption `json:"options"`
+	TotalVoterCount       int          `json:"total_voter_count"`
+	IsClosed              bool         `json:"is_closed"`
+	IsAnonymous           bool         `json:"is_anonymous"`
+	Type                  string       `json:"type"`
+	AllowsMultipleAnswers bool         `json:"allows_multiple_answers"`
+}
+
 type PreCheckoutQuery struct {
+	Bot              Bot
 	Id               string    `json:"id"`
 	From             *User     `json:"from"`
 	Currency         string    `json:"currency"`
@@ -106,6 +128,7 @@ type Message struct {
 	Bot                   Bot
 	MessageId             int                `json:"message_id"`
 	From                  *User              `json:"from"`
+	SenderChat            *Chat              `json:"sender_chat"`
 	Date                  int                `json:"date"`
 	Chat                  *Chat              `json:"chat"`
 	ForwardFrom           *User              `json:"forward_from"`
@@ -114,6 +137,7 @@ type Message struct {
 	ForwardSignature      string             `json:"forward_signature"`
 	ForwardDate           int                `json:"forward_date"`
 	ReplyToMessage        *Message           `json:"reply_to_message"`
+	ViaBot                *User              `json:"via_bot"`
 	EditDate              int                `json:"edit_date"`
 	AuthorSignature       string             `json:"author_signature"`
 	Text                  string             `json:"text"`
@@ -131,6 +155,8 @@ type Message struct {
 	NewChatMembers        []User             `json:"new_chat_members"`
 	Caption               string             `json:"caption"`
 	Contact               *Contact           `json:"contact"`
+	Dice                  *Dice              `json:"dice"`
+	Poll                  *Poll              `json:"poll"`
 	Location              *Location          `json:"location"`
 	Venue                 *Venue             `json:"venue"`
 	LeftChatMember        *User              `json:"left_chat_member"`
@@ -83,7 +83,7 @@ func (b Bot) GetStickerSet(name string) (*StickerSet, error) {
 		return nil, errors.Wrapf(err, "unable to getStickerSet")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("getStickerSet", r)
 	}
 
 	var ss StickerSet
@@ -156,7 +156,7 @@ func (b Bot) SetStickerPositionInSet(sticker string, position int) (bool, error)
 		return false, errors.Wrapf(err, "unable to setStickerPositionInSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("setStickerPositionInSet", r)
 	}
 
 	var bb bool
@@ -174,7 +174,7 @@ func (b Bot) DeleteStickerFromSet(sticker string) (bool, error) {
 		return false, errors.Wrapf(err, "unable to deleteStickerFromSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("deleteStickerFromSet", r)
 	}
 
 	var bb bool
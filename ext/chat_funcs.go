@@ -30,7 +30,7 @@ func (b Bot) UnbanChatMember(chatId int, userId int) (bool, error) {
 		return false, errors.Wrapf(err, "could not unbanChatMember")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("unbanChatMember", r)
 	}
 
 	var bb bool
@@ -80,7 +80,7 @@ func (b Bot) ExportChatInviteLink(chatId int) (string, error) {
 		return "", errors.Wrapf(err, "unable to exportChatInviteLink")
 	}
 	if !r.Ok {
-		return "", errors.New(r.Description)
+		return "", NewTelegramError("exportChatInviteLink", r)
 	}
 
 	var s string
@@ -116,7 +116,7 @@ func (b Bot) DeleteChatPhoto(chatId int) (bool, error) {
 		return false, errors.Wrapf(err, "unable to deleteChatPhoto")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("deleteChatPhoto", r)
 	}
 
 	var bb bool
@@ -135,7 +135,7 @@ func (b Bot) SetChatTitle(chatId int, title string) (bool, error) {
 		return false, errors.Wrapf(err, "unable to setChatTitle")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("setChatTitle", r)
 	}
 
 	var bb bool
@@ -154,7 +154,7 @@ func (b Bot) SetChatDescription(chatId int, description string) (bool, error) {
 		return false, errors.Wrapf(err, "unable to setChatDescription")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("setChatDescription", r)
 	}
 
 	var bb bool
@@ -183,7 +183,7 @@ func (b Bot) UnpinChatMessage(chatId int) (bool, error) {
 		return false, errors.Wrapf(err, "unable to unpinChatMessage")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("unpinChatMessage", r)
 	}
 
 	var bb bool
@@ -201,7 +201,7 @@ func (b Bot) LeaveChat(chatId int) (bool, error) {
 		return false, errors.Wrapf(err, "unable to leaveChat")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("leaveChat", r)
 	}
 
 	var bb bool
@@ -219,12 +219,12 @@ func (b Bot) GetChat(chatId int) (*Chat, error) {
 		return nil, errors.Wrapf(err, "unable to getChat")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("getChat", r)
 	}
 
 	var c Chat
 	json.Unmarshal(r.Result, &c)
-	c.Bot = b
+	c.Bot = b.detached()
 
 	return &c, nil
 }
@@ -238,7 +238,7 @@ func (b Bot) GetChatAdministrators(chatId int) ([]ChatMember, error) {
 		return nil, errors.Wrapf(err, "unable to getChatAdministrators")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("getChatAdministrators", r)
 	}
 
 	var cm []ChatMember
@@ -256,7 +256,7 @@ func (b Bot) GetChatMembersCount(chatId int) (int, error) {
 		return 0, errors.Wrapf(err, "unable to getChatMembersCount")
 	}
 	if !r.Ok {
-		return 0, errors.New(r.Description)
+		return 0, NewTelegramError("getChatMembersCount", r)
 	}
 
 	var c int
@@ -275,7 +275,7 @@ func (b Bot) GetChatMember(chatId int, userId int) (*ChatMember, error) {
 		return nil, errors.Wrapf(err, "unable to getChatMember")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("getChatMember", r)
 	}
 
 	var cm ChatMember
@@ -294,7 +294,7 @@ func (b Bot) SetChatStickerSet(chatId int, stickerSetName string) (bool, error)
 		return false, errors.Wrapf(err, "unable to setChatStickerSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("setChatStickerSet", r)
 	}
 
 	var bb bool
@@ -312,7 +312,7 @@ func (b Bot) DeleteChatStickerSet(chatId int) (bool, error) {
 		return false, errors.Wrapf(err, "unable to deleteChatStickerSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("deleteChatStickerSet", r)
 	}
 
 	var bb bool
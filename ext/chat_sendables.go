@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -24,18 +25,23 @@ func (b Bot) NewSendableKickChatMember(chatId int, userId int) *sendableKickChat
 }
 
 func (kcm *sendableKickChatMember) Send() (bool, error) {
+	return kcm.SendCtx(kcm.bot.Context())
+}
+
+func (kcm *sendableKickChatMember) SendCtx(ctx context.Context) (bool, error) {
+	bot := kcm.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(kcm.ChatId))
 	v.Add("user_id", strconv.Itoa(kcm.UserId))
 	v.Add("until_date", strconv.FormatInt(kcm.UntilDate, 10))
 
-	r, err := Get(kcm.bot, "kickChatMember", v)
+	r, err := Get(bot, "kickChatMember", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "could not kickChatMember")
 	}
 
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("kickChatMember", r)
 	}
 	var bb bool
 	json.Unmarshal(r.Result, &bb)
@@ -68,6 +74,11 @@ func (b Bot) NewSendableRestrictChatMember(chatId int, userId int) *sendableRest
 }
 
 func (rcm *sendableRestrictChatMember) Send() (bool, error) {
+	return rcm.SendCtx(rcm.bot.Context())
+}
+
+func (rcm *sendableRestrictChatMember) SendCtx(ctx context.Context) (bool, error) {
+	bot := rcm.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(rcm.ChatId))
 	v.Add("user_id", strconv.Itoa(rcm.UserId))
@@ -77,12 +88,12 @@ func (rcm *sendableRestrictChatMember) Send() (bool, error) {
 	v.Add("can_send_other_messages", strconv.FormatBool(rcm.CanSendOtherMessages))
 	v.Add("can_add_web_page_previews", strconv.FormatBool(rcm.CanAddWebPagePreviews))
 
-	r, err := Get(rcm.bot, "restrictChatMember", v)
+	r, err := Get(bot, "restrictChatMember", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "could not restrictChatMember")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("restrictChatMember", r)
 	}
 
 	var bb bool
@@ -123,6 +134,11 @@ func (b Bot) NewSendablePromoteChatMember(chatId int, userId int) *sendablePromo
 }
 
 func (rcm *sendablePromoteChatMember) Send() (bool, error) {
+	return rcm.SendCtx(rcm.bot.Context())
+}
+
+func (rcm *sendablePromoteChatMember) SendCtx(ctx context.Context) (bool, error) {
+	bot := rcm.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(rcm.ChatId))
 	v.Add("user_id", strconv.Itoa(rcm.UserId))
@@ -135,12 +151,12 @@ func (rcm *sendablePromoteChatMember) Send() (bool, error) {
 	v.Add("can_pin_messages", strconv.FormatBool(rcm.CanPinMessages))
 	v.Add("can_promote_members", strconv.FormatBool(rcm.CanPromoteMembers))
 
-	r, err := Get(rcm.bot, "promoteChatMember", v)
+	r, err := Get(bot, "promoteChatMember", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "could not promoteChatMember")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("promoteChatMember", r)
 	}
 
 	var bb bool
@@ -166,17 +182,22 @@ func (b Bot) NewSendablePinChatMessage(chatId int, messageId int) *sendablePinCh
 }
 
 func (pcm *sendablePinChatMessage) Send() (bool, error) {
+	return pcm.SendCtx(pcm.bot.Context())
+}
+
+func (pcm *sendablePinChatMessage) SendCtx(ctx context.Context) (bool, error) {
+	bot := pcm.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(pcm.ChatId))
 	v.Add("message_id", strconv.Itoa(pcm.MessageId))
 	v.Add("disable_notification", strconv.FormatBool(pcm.DisableNotification))
 
-	r, err := Get(pcm.bot, "pinChatMessage", v)
+	r, err := Get(bot, "pinChatMessage", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to pinChatMessage")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("pinChatMessage", r)
 	}
 
 	var bb bool
@@ -196,18 +217,23 @@ func (b Bot) NewSendableSetChatPhoto(chatId int) *sendableSetChatPhoto {
 }
 
 func (scp *sendableSetChatPhoto) Send() (bool, error) {
+	return scp.SendCtx(scp.bot.Context())
+}
+
+func (scp *sendableSetChatPhoto) SendCtx(ctx context.Context) (bool, error) {
+	bot := scp.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(scp.ChatId))
 
-	r, err := scp.bot.sendFile(scp.file, "photo", "setChatPhoto", v)
+	r, err := bot.sendFile(scp.file, "photo", "setChatPhoto", v)
 
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to setChatPhoto")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("setChatPhoto", r)
 	}
-	var newMsg bool
-	json.Unmarshal(r.Result, newMsg)
-	return newMsg, nil
+	var bb bool
+	json.Unmarshal(r.Result, &bb)
+	return bb, nil
 }
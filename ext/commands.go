@@ -0,0 +1,96 @@
+package ext
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// BotCommand is an entry in the command list telegram shows users.
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+const (
+	BotCommandScopeDefault               = "default"
+	BotCommandScopeAllPrivateChats       = "all_private_chats"
+	BotCommandScopeAllGroupChats         = "all_group_chats"
+	BotCommandScopeAllChatAdministrators = "all_chat_administrators"
+	BotCommandScopeChat                  = "chat"
+	BotCommandScopeChatAdministrators    = "chat_administrators"
+	BotCommandScopeChatMember            = "chat_member"
+)
+
+// BotCommandScope determines which users see a command list. ChatId is only used by the chat scopes, and UserId by
+// the chat member scope.
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatId int    `json:"chat_id,omitempty"`
+	UserId int    `json:"user_id,omitempty"`
+}
+
+// SetMyCommands sets the bot's command list for the scope, and for users with the language, if given. A nil scope
+// is the default scope.
+func (b Bot) SetMyCommands(commands []BotCommand, scope *BotCommandScope, languageCode string) (bool, error) {
+	if commands == nil {
+		commands = []BotCommand{}
+	}
+	cmds, err := json.Marshal(commands)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not marshal bot commands")
+	}
+	v, err := commandScopeValues(scope, languageCode)
+	if err != nil {
+		return false, err
+	}
+	v.Add("commands", string(cmds))
+
+	return b.boolSender("setMyCommands", v)
+}
+
+// GetMyCommands returns the bot's command list for the scope and language.
+func (b Bot) GetMyCommands(scope *BotCommandScope, languageCode string) ([]BotCommand, error) {
+	v, err := commandScopeValues(scope, languageCode)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := Get(b, "getMyCommands", v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to getMyCommands")
+	}
+	if !r.Ok {
+		return nil, NewTelegramError("getMyCommands", r)
+	}
+
+	var cmds []BotCommand
+	return cmds, json.Unmarshal(r.Result, &cmds)
+}
+
+// DeleteMyCommands deletes the bot's command list for the scope and language, so that users see the list of a
+// broader scope instead.
+func (b Bot) DeleteMyCommands(scope *BotCommandScope, languageCode string) (bool, error) {
+	v, err := commandScopeValues(scope, languageCode)
+	if err != nil {
+		return false, err
+	}
+
+	return b.boolSender("deleteMyCommands", v)
+}
+
+func commandScopeValues(scope *BotCommandScope, languageCode string) (url.Values, error) {
+	v := url.Values{}
+	if scope != nil {
+		s, err := json.Marshal(scope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not marshal bot command scope")
+		}
+		v.Add("scope", string(s))
+	}
+	if languageCode != "" {
+		v.Add("language_code", languageCode)
+	}
+	return v, nil
+}
@@ -0,0 +1,36 @@
+package ext
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TelegramError is returned whenever the telegram API rejects a request. It can be retrieved with errors.As to
+// handle specific failures, such as being blocked (403), flood limits (429), or group migrations.
+type TelegramError struct {
+	Method      string
+	Code        int
+	Description string
+	// Params is nil if telegram did not send any response parameters.
+	Params *ResponseParameters
+}
+
+func (t *TelegramError) Error() string {
+	return fmt.Sprintf("%s failed with error code %d: %s", t.Method, t.Code, t.Description)
+}
+
+// NewTelegramError builds a TelegramError from an unsuccessful API response to the given method.
+func NewTelegramError(method string, r *Response) *TelegramError {
+	te := &TelegramError{
+		Method:      method,
+		Code:        r.ErrorCode,
+		Description: r.Description,
+	}
+	if len(r.Parameters) > 0 {
+		var params ResponseParameters
+		if err := json.Unmarshal(r.Parameters, &params); err == nil {
+			te.Params = &params
+		}
+	}
+	return te
+}
@@ -0,0 +1,84 @@
+package ext_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/sirupsen/logrus"
+)
+
+var testUser = gotgbottest.User{Id: 42, FirstName: "Ann"}
+
+func newTestBot(t *testing.T) (*gotgbottest.Server, ext.Bot) {
+	t.Helper()
+	srv := gotgbottest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: srv.Requester()}
+}
+
+func TestTelegramErrorHasResponseParameters(t *testing.T) {
+	srv, b := newTestBot(t)
+	srv.Handle("sendMessage", func(c gotgbottest.Call) (interface{}, error) {
+		return nil, &ext.TelegramError{
+			Code:        http.StatusBadRequest,
+			Description: "Bad Request: group chat was upgraded to a supergroup chat",
+			Params:      &ext.ResponseParameters{MigrateToChatId: -1001},
+		}
+	})
+
+	_, err := b.SendMessage(-1, "hi")
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) {
+		t.Fatalf("expected a TelegramError, got %v", err)
+	}
+	if tgErr.Method != "sendMessage" || tgErr.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected error %+v", tgErr)
+	}
+	if tgErr.Params == nil || tgErr.Params.MigrateToChatId != -1001 {
+		t.Fatalf("response parameters not decoded: %+v", tgErr.Params)
+	}
+}
+
+func TestTelegramErrorWithoutParameters(t *testing.T) {
+	_, b := newTestBot(t)
+	_, err := b.GetChat(999)
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) {
+		t.Fatalf("expected a TelegramError, got %v", err)
+	}
+	if tgErr.Method != "getChat" || tgErr.Description != "Bad Request: chat not found" || tgErr.Params != nil {
+		t.Fatalf("unexpected error %+v", tgErr)
+	}
+}
+
+func TestEditMessageReplyMarkupCallsItsOwnMethod(t *testing.T) {
+	srv, b := newTestBot(t)
+	chat := srv.PrivateChat(testUser)
+	msg, err := b.SendMessage(chat.Id, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markup := &ext.InlineKeyboardMarkup{InlineKeyboard: &[][]ext.InlineKeyboardButton{{{Text: "a", CallbackData: "a"}}}}
+	if _, err := b.NewSendableEditMessageReplyMarkup(chat.Id, msg.MessageId, markup).Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.CallsTo("editMessageReplyMarkup")) != 1 || len(srv.CallsTo("editMessageCaption")) != 0 {
+		t.Fatalf("unexpected calls %+v", srv.Calls())
+	}
+}
+
+func TestBoolResultsAreDecoded(t *testing.T) {
+	srv, b := newTestBot(t)
+	chat := srv.PrivateChat(testUser)
+	ok, err := b.SendChatAction(chat.Id, "typing")
+	if err != nil || !ok {
+		t.Fatalf("sendChatAction returned %v, %v", ok, err)
+	}
+	ok, err = b.SetChatPhotoStr(chat.Id, "photo-id")
+	if err != nil || !ok {
+		t.Fatalf("setChatPhoto returned %v, %v", ok, err)
+	}
+}
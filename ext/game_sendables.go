@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -22,19 +23,24 @@ func (b Bot) NewSendableGame(chatId int, gameShortName string) *sendableGame {
 }
 
 func (g *sendableGame) Send() (*Message, error) {
+	return g.SendCtx(g.bot.Context())
+}
+
+func (g *sendableGame) SendCtx(ctx context.Context) (*Message, error) {
+	bot := g.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(g.ChatId))
 	v.Add("game_short_name", g.GameShortName)
 
-	r, err := Get(g.bot, "sendGame", v)
+	r, err := Get(bot, "sendGame", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to execute sendGame request")
 	}
 	if !r.Ok {
-		return nil, errors.Wrapf(err, "invalid sendGame request")
+		return nil, NewTelegramError("sendGame", r)
 	}
 
-	return g.bot.ParseMessage(r.Result), nil
+	return bot.ParseMessage(r.Result), nil
 }
 
 type sendableSetGameScore struct {
@@ -57,6 +63,11 @@ func (b Bot) NewSendableSetGameScoreInline(userId int, score int, inlineMessageI
 }
 
 func (sgs *sendableSetGameScore) Send() (bool, error) {
+	return sgs.SendCtx(sgs.bot.Context())
+}
+
+func (sgs *sendableSetGameScore) SendCtx(ctx context.Context) (bool, error) {
+	bot := sgs.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("user_id", strconv.Itoa(sgs.UserId))
 	v.Add("score", strconv.Itoa(sgs.Score))
@@ -66,12 +77,12 @@ func (sgs *sendableSetGameScore) Send() (bool, error) {
 	v.Add("message_id", strconv.Itoa(sgs.MessageId))
 	v.Add("inline_message_id", sgs.InlineMessageId)
 
-	r, err := Get(sgs.bot, "setGameScore", v)
+	r, err := Get(bot, "setGameScore", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to execute setGameScore request")
 	}
 	if !r.Ok {
-		return false, errors.Wrapf(err, "invalid setGameScore request")
+		return false, NewTelegramError("setGameScore", r)
 	}
 
 	var bb bool
@@ -97,18 +108,23 @@ func (b Bot) NewSendableGetGameHighScoreInline(userId int, inlineMessageId strin
 }
 
 func (gghs *sendableGetGameHighScores) Send() ([]GameHighScore, error) {
+	return gghs.SendCtx(gghs.bot.Context())
+}
+
+func (gghs *sendableGetGameHighScores) SendCtx(ctx context.Context) ([]GameHighScore, error) {
+	bot := gghs.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("user_id", strconv.Itoa(gghs.UserId))
 	v.Add("chat_id", strconv.Itoa(gghs.ChatId))
 	v.Add("message_id", strconv.Itoa(gghs.MessageId))
 	v.Add("inline_message_id", gghs.InlineMessageId)
 
-	r, err := Get(gghs.bot, "getGameHighScores", v)
+	r, err := Get(bot, "getGameHighScores", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to execute getGameHighScores request")
 	}
 	if !r.Ok {
-		return nil, errors.Wrapf(err, "invalid getGameHighScores request")
+		return nil, NewTelegramError("getGameHighScores", r)
 	}
 
 	var ghs []GameHighScore
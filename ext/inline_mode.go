@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -9,280 +10,333 @@ import (
 )
 
 type InlineQuery struct {
-	Id       string   `json:"id"`
-	From     User     `json:"from"`
-	Location Location `json:"location"`
-	Query    string   `json:"query"`
-	Offset   string   `json:"offset"`
+	Bot      Bot
+	Id       string    `json:"id"`
+	From     *User     `json:"from"`
+	Location *Location `json:"location"`
+	Query    string    `json:"query"`
+	Offset   string    `json:"offset"`
+}
+
+// MaxInlineQueryResults is the maximum number of results telegram accepts in a single answer.
+const MaxInlineQueryResults = 50
+
+// Answer answers the inline query with the given results.
+func (iq InlineQuery) Answer(results ...InlineResult) (bool, error) {
+	return iq.Bot.AnswerInlineQuery(iq.Id, results)
+}
+
+// AnswerPage answers the inline query with the page of results starting at the query's offset. When the user
+// scrolls to the end of the page, telegram sends the query again with the offset of the next page, so the same
+// results can be passed every time. A pageSize of 0 uses MaxInlineQueryResults.
+func (iq InlineQuery) AnswerPage(results []InlineResult, pageSize int) (bool, error) {
+	return iq.NewAnswerPage(results, pageSize).Send()
+}
+
+// NewAnswerPage is the same as AnswerPage, but returns the answer without sending it, so that it can be customised.
+func (iq InlineQuery) NewAnswerPage(results []InlineResult, pageSize int) *sendableAnswerInlineQuery {
+	if pageSize <= 0 || pageSize > MaxInlineQueryResults {
+		pageSize = MaxInlineQueryResults
+	}
+	start, err := strconv.Atoi(iq.Offset)
+	if err != nil || start < 0 {
+		start = 0
+	} else if start > len(results) {
+		start = len(results)
+	}
+	end := start + pageSize
+	if end > len(results) {
+		end = len(results)
+	}
+
+	answer := iq.Bot.NewSendableAnswerInlineQuery(iq.Id, results[start:end])
+	if end < len(results) {
+		answer.NextOffset = strconv.Itoa(end)
+	}
+	return answer
+}
+
+// InlineResult is implemented by all InlineQueryResult types, which embed InlineQueryResult.
+type InlineResult interface {
+	isInlineQueryResult()
 }
 
 type InlineQueryResult struct{}
 
+func (InlineQueryResult) isInlineQueryResult() {}
+
 type InlineQueryResultArticle struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Title               string               `json:"title"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	Url                 string               `json:"url"`
-	HideUrl             bool                 `json:"hide_url"`
-	Description         string               `json:"description"`
-	ThumbUrl            string               `json:"thumb_url"`
-	ThumbWidth          int                  `json:"thumb_width"`
-	ThumbHeight         int                  `json:"thumb_height"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Title               string                `json:"title,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	Url                 string                `json:"url,omitempty"`
+	HideUrl             bool                  `json:"hide_url,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	ThumbWidth          int                   `json:"thumb_width,omitempty"`
+	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
 type InlineQueryResultPhoto struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	PhotoUrl            string               `json:"photo_url"`
-	ThumbUrl            string               `json:"thumb_url"`
-	PhotoWidth          int                  `json:"photo_width"`
-	PhotoHeight         int                  `json:"photo_height"`
-	Title               string               `json:"title"`
-	Description         string               `json:"description"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	PhotoUrl            string                `json:"photo_url,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	PhotoWidth          int                   `json:"photo_width,omitempty"`
+	PhotoHeight         int                   `json:"photo_height,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultGif struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	GifUrl              string               `json:"gif_url"`
-	GifWidth            int                  `json:"gif_width"`
-	GifHeight           int                  `json:"gif_height"`
-	GifDuration         int                  `json:"gif_duration"`
-	ThumbUrl            string               `json:"thumb_url"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	GifUrl              string                `json:"gif_url,omitempty"`
+	GifWidth            int                   `json:"gif_width,omitempty"`
+	GifHeight           int                   `json:"gif_height,omitempty"`
+	GifDuration         int                   `json:"gif_duration,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultMpeg4Gif struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Mpeg4Url            string               `json:"mpeg4_url"`
-	Mpeg4Width          int                  `json:"mpeg4_width"`
-	Mpeg4Height         int                  `json:"mpeg4_height"`
-	Mpeg4Duration       int                  `json:"mpeg4_duration"`
-	ThumbUrl            string               `json:"thumb_url"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Mpeg4Url            string                `json:"mpeg4_url,omitempty"`
+	Mpeg4Width          int                   `json:"mpeg4_width,omitempty"`
+	Mpeg4Height         int                   `json:"mpeg4_height,omitempty"`
+	Mpeg4Duration       int                   `json:"mpeg4_duration,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultVideo struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	VideoUrl            string               `json:"video_url"`
-	MimeType            string               `json:"mime_type"`
-	ThumbUrl            string               `json:"thumb_url"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	VideoWidth          int                  `json:"video_width"`
-	VideoHeight         int                  `json:"video_height"`
-	VideoDuration       int                  `json:"video_duration"`
-	Description         string               `json:"description"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	VideoUrl            string                `json:"video_url,omitempty"`
+	MimeType            string                `json:"mime_type,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	VideoWidth          int                   `json:"video_width,omitempty"`
+	VideoHeight         int                   `json:"video_height,omitempty"`
+	VideoDuration       int                   `json:"video_duration,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultAudio struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	AudioUrl            string               `json:"audio_url"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	Performer           string               `json:"performer"`
-	AudioDuration       int                  `json:"audio_duration"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	AudioUrl            string                `json:"audio_url,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	Performer           string                `json:"performer,omitempty"`
+	AudioDuration       int                   `json:"audio_duration,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultVoice struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	VoiceUrl            string               `json:"voice_url"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	VoiceDuration       int                  `json:"voice_duration"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	VoiceUrl            string                `json:"voice_url,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	VoiceDuration       int                   `json:"voice_duration,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultDocument struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	DocumentUrl         string               `json:"document_url"`
-	MimeType            string               `json:"mime_type"`
-	Description         string               `json:"description"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
-	ThumbUrl            string               `json:"thumb_url"`
-	ThumbWidth          int                  `json:"thumb_width"`
-	ThumbHeight         int                  `json:"thumb_height"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	DocumentUrl         string                `json:"document_url,omitempty"`
+	MimeType            string                `json:"mime_type,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	ThumbWidth          int                   `json:"thumb_width,omitempty"`
+	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
 type InlineQueryResultLocation struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Latitude            float64              `json:"latitude"`
-	Longitude           float64              `json:"longitude"`
-	Title               string               `json:"title"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
-	ThumbUrl            string               `json:"thumb_url"`
-	ThumbWidth          int                  `json:"thumb_width"`
-	ThumbHeight         int                  `json:"thumb_height"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Latitude            float64               `json:"latitude"`
+	Longitude           float64               `json:"longitude"`
+	Title               string                `json:"title,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	ThumbWidth          int                   `json:"thumb_width,omitempty"`
+	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
 type InlineQueryResultVenue struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Latitude            float64              `json:"latitude"`
-	Longitude           float64              `json:"longitude"`
-	Title               string               `json:"title"`
-	Address             string               `json:"address"`
-	FoursquareId        string               `json:"foursquare_id"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
-	ThumbUrl            string               `json:"thumb_url"`
-	ThumbWidth          int                  `json:"thumb_width"`
-	ThumbHeight         int                  `json:"thumb_height"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Latitude            float64               `json:"latitude"`
+	Longitude           float64               `json:"longitude"`
+	Title               string                `json:"title,omitempty"`
+	Address             string                `json:"address,omitempty"`
+	FoursquareId        string                `json:"foursquare_id,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	ThumbWidth          int                   `json:"thumb_width,omitempty"`
+	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
 type InlineQueryResultContact struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	PhoneNumber         string               `json:"phone_number"`
-	FirstName           string               `json:"first_name"`
-	LastName            string               `json:"last_name"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
-	ThumbUrl            string               `json:"thumb_url"`
-	ThumbWidth          int                  `json:"thumb_width"`
-	ThumbHeight         int                  `json:"thumb_height"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	PhoneNumber         string                `json:"phone_number,omitempty"`
+	FirstName           string                `json:"first_name,omitempty"`
+	LastName            string                `json:"last_name,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+	ThumbUrl            string                `json:"thumb_url,omitempty"`
+	ThumbWidth          int                   `json:"thumb_width,omitempty"`
+	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
 type InlineQueryResultGame struct {
 	InlineQueryResult
-	Type          string               `json:"type"`
-	Id            string               `json:"id"`
-	GameShortName string               `json:"game_short_name"`
-	ReplyMarkup   InlineKeyboardMarkup `json:"reply_markup"`
+	Type          string                `json:"type"`
+	Id            string                `json:"id"`
+	GameShortName string                `json:"game_short_name,omitempty"`
+	ReplyMarkup   *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 type InlineQueryResultCachedPhoto struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	PhotoFileId         string               `json:"photo_file_id"`
-	Title               string               `json:"title"`
-	Description         string               `json:"description"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	PhotoFileId         string                `json:"photo_file_id,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedGif struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	GifFileId           string               `json:"gif_file_id"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	GifFileId           string                `json:"gif_file_id,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedMpeg4Gif struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Mpeg4FileId         string               `json:"mpeg4_file_id"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Mpeg4FileId         string                `json:"mpeg4_file_id,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedSticker struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	StickerFileId       string               `json:"sticker_file_id"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	StickerFileId       string                `json:"sticker_file_id,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedDocument struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	Title               string               `json:"title"`
-	DocumentFileId      string               `json:"document_file_id"`
-	Description         string               `json:"description"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	Title               string                `json:"title,omitempty"`
+	DocumentFileId      string                `json:"document_file_id,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedVideo struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	VideoFileId         string               `json:"video_file_id"`
-	Title               string               `json:"title"`
-	Description         string               `json:"description"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	VideoFileId         string                `json:"video_file_id,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Description         string                `json:"description,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedVoice struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	VoiceFileId         string               `json:"voice_file_id"`
-	Title               string               `json:"title"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	VoiceFileId         string                `json:"voice_file_id,omitempty"`
+	Title               string                `json:"title,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
 }
 
 type InlineQueryResultCachedAudio struct {
 	InlineQueryResult
-	Type                string               `json:"type"`
-	Id                  string               `json:"id"`
-	AudioFileId         string               `json:"audio_file_id"`
-	Caption             string               `json:"caption"`
-	ReplyMarkup         InlineKeyboardMarkup `json:"reply_markup"`
-	InputMessageContent InputMessageContent  `json:"input_message_content"`
+	Type                string                `json:"type"`
+	Id                  string                `json:"id"`
+	AudioFileId         string                `json:"audio_file_id,omitempty"`
+	Caption             string                `json:"caption,omitempty"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	InputMessageContent MessageContent        `json:"input_message_content,omitempty"`
+}
+
+// MessageContent is implemented by all Input*MessageContent types, which embed InputMessageContent.
+type MessageContent interface {
+	isInputMessageContent()
 }
 
 type InputMessageContent struct{}
 
+func (InputMessageContent) isInputMessageContent() {}
+
 type InputTextMessageContent struct {
 	InputMessageContent
-	MessageText           string `json:"message_text"`
-	ParseMode             string `json:"parse_mode"`
-	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+	MessageText           string `json:"message_text,omitempty"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
 }
 
 type InputLocationMessageContent struct {
@@ -295,19 +349,20 @@ type InputVenueMessageContent struct {
 	InputMessageContent
 	Latitude     float64 `json:"latitude"`
 	Longitude    float64 `json:"longitude"`
-	Title        string  `json:"title"`
-	Address      string  `json:"address"`
-	FoursquareId string  `json:"foursquare_id"`
+	Title        string  `json:"title,omitempty"`
+	Address      string  `json:"address,omitempty"`
+	FoursquareId string  `json:"foursquare_id,omitempty"`
 }
 
 type InputContactMessageContent struct {
 	InputMessageContent
-	PhoneNumber string `json:"phone_number"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
 }
 
 type ChosenInlineResult struct {
+	Bot             Bot
 	ResultId        string   `json:"result_id"`
 	From            *User    `json:"from"`
 	Location        Location `json:"location"`
@@ -318,7 +373,7 @@ type ChosenInlineResult struct {
 type sendableAnswerInlineQuery struct {
 	bot               Bot
 	InlineQueryId     string
-	Results           []InlineQueryResult
+	Results           []InlineResult
 	CacheTime         int
 	IsPersonal        bool
 	NextOffset        string
@@ -326,12 +381,21 @@ type sendableAnswerInlineQuery struct {
 	SwitchPmParameter string
 }
 
-func (b Bot) NewSendableAnswerInlineQuery(inlineQueryId string, results []InlineQueryResult) *sendableAnswerInlineQuery {
+func (b Bot) NewSendableAnswerInlineQuery(inlineQueryId string, results []InlineResult) *sendableAnswerInlineQuery {
 	return &sendableAnswerInlineQuery{bot: b, InlineQueryId: inlineQueryId, Results: results}
 }
 
 func (aiq sendableAnswerInlineQuery) Send() (bool, error) {
-	resultsStr, err := json.Marshal(aiq.Results)
+	return aiq.SendCtx(aiq.bot.Context())
+}
+
+func (aiq sendableAnswerInlineQuery) SendCtx(ctx context.Context) (bool, error) {
+	bot := aiq.bot.WithContext(ctx)
+	results := aiq.Results
+	if results == nil {
+		results = []InlineResult{} // telegram expects an array, even if there are no results
+	}
+	resultsStr, err := json.Marshal(results)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to unmarshal answerInlineQuery result")
 	}
@@ -344,12 +408,12 @@ func (aiq sendableAnswerInlineQuery) Send() (bool, error) {
 	v.Add("switch_pm_text", aiq.SwitchPmText)
 	v.Add("switch_pm_parameter", aiq.SwitchPmParameter)
 
-	r, err := Get(aiq.bot, "answerInlineQuery", v)
+	r, err := Get(bot, "answerInlineQuery", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to execute answerInlineQuery request")
 	}
 	if !r.Ok {
-		return false, errors.Wrapf(err, "invalid answerInlineQuery request")
+		return false, NewTelegramError("answerInlineQuery", r)
 	}
 
 	var bb bool
@@ -357,6 +421,6 @@ func (aiq sendableAnswerInlineQuery) Send() (bool, error) {
 	return bb, nil
 }
 
-func (b Bot) AnswerInlineQuery(inlineQueryId string, results []InlineQueryResult) (bool, error) {
+func (b Bot) AnswerInlineQuery(inlineQueryId string, results []InlineResult) (bool, error) {
 	return b.NewSendableAnswerInlineQuery(inlineQueryId, results).Send()
 }
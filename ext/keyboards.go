@@ -2,6 +2,9 @@ package ext
 
 import (
 	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 )
@@ -82,17 +85,19 @@ func (rkm *InlineKeyboardMarkup) Marshal() ([]byte, error) {
 	return inlineKBMarkup, nil
 }
 
+// InlineKeyboardButton must have exactly one of its optional fields set.
 type InlineKeyboardButton struct {
 	Text                         string `json:"text"`
-	Url                          string `json:"url"`
-	CallbackData                 string `json:"callback_data"`
-	SwitchInlineQuery            string `json:"switch_inline_query"`
-	SwitchInlineQueryCurrentChat string `json:"switch_inline_query_current_chat"`
+	Url                          string `json:"url,omitempty"`
+	CallbackData                 string `json:"callback_data,omitempty"`
+	SwitchInlineQuery            string `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat string `json:"switch_inline_query_current_chat,omitempty"`
 	//Callback_game                    *CallbackGame
-	Pay bool `json:"pay"`
+	Pay bool `json:"pay,omitempty"`
 }
 
 type CallbackQuery struct {
+	Bot             Bot
 	Id              string   `json:"id"`
 	From            *User    `json:"from"`
 	Message         *Message `json:"message"`
@@ -100,6 +105,103 @@ type CallbackQuery struct {
 	ChatInstance    string   `json:"chat_instance"`
 	Data            string   `json:"data"`
 	GameShortName   string   `json:"game_short_name"`
+	// Value is the value stored for the button by a gotgbot.CallbackDataCache, if any.
+	Value interface{} `json:"-"`
+
+	answered int32
+}
+
+// ErrCallbackQueryAnswered is returned when answering a callback query which has already been answered.
+var ErrCallbackQueryAnswered = errors.New("callback query has already been answered")
+
+// Answer answers the callback query without showing the user anything. Telegram's client shows a progress bar on the
+// button until the query is answered.
+// Queries answered through their own methods can't be answered twice. Answering them by id with the Bot's
+// AnswerCallbackQuery methods only counts while they are tracked; see TrackAnswers.
+func (cq *CallbackQuery) Answer() (bool, error) {
+	return cq.answer(func() (bool, error) { return cq.Bot.AnswerCallbackQuery(cq.Id) })
+}
+
+// AnswerText answers the callback query with a notification at the top of the chat, or with an alert if alert is set.
+func (cq *CallbackQuery) AnswerText(text string, alert bool) (bool, error) {
+	return cq.answer(func() (bool, error) { return cq.Bot.AnswerCallbackQueryText(cq.Id, text, alert) })
+}
+
+// AnswerURL answers the callback query by opening the URL, which must be a game or a t.me link to the bot.
+func (cq *CallbackQuery) AnswerURL(URL string) (bool, error) {
+	return cq.answer(func() (bool, error) { return cq.Bot.AnswerCallbackQueryURL(cq.Id, URL) })
+}
+
+// EnsureAnswered answers the callback query without showing the user anything, unless it has already been answered.
+// Queries which telegram says are too old to answer are ignored, as there is nothing left to do for them.
+func (cq *CallbackQuery) EnsureAnswered() error {
+	if _, err := cq.Answer(); err != nil && err != ErrCallbackQueryAnswered && !isQueryTooOld(err) {
+		return err
+	}
+	return nil
+}
+
+// Answered reports whether the callback query has been answered through one of its methods, or by id while it was
+// tracked.
+func (cq *CallbackQuery) Answered() bool {
+	return atomic.LoadInt32(&cq.answered) == 1
+}
+
+// TrackAnswers makes answering the callback query by id, through its bot's AnswerCallbackQuery methods, mark it as
+// answered, until the returned function is called. The dispatcher tracks each callback query while handling it.
+func (cq *CallbackQuery) TrackAnswers() (release func()) {
+	key := trackedQueryKey{token: cq.Bot.Token, id: cq.Id}
+	trackedQueries.Lock()
+	defer trackedQueries.Unlock()
+	if _, ok := trackedQueries.queries[key]; ok {
+		return func() {} // already tracked
+	}
+	trackedQueries.queries[key] = cq
+	return func() {
+		trackedQueries.Lock()
+		delete(trackedQueries.queries, key)
+		trackedQueries.Unlock()
+	}
+}
+
+type trackedQueryKey struct {
+	token string
+	id    string
+}
+
+// trackedQueries holds the callback queries whose answers by id are being tracked.
+var trackedQueries = struct {
+	sync.Mutex
+	queries map[trackedQueryKey]*CallbackQuery
+}{queries: map[trackedQueryKey]*CallbackQuery{}}
+
+// markAnswered marks the tracked callback query with the given id, if any, as answered.
+func markAnswered(token string, id string) {
+	trackedQueries.Lock()
+	cq, ok := trackedQueries.queries[trackedQueryKey{token: token, id: id}]
+	trackedQueries.Unlock()
+	if ok {
+		atomic.StoreInt32(&cq.answered, 1)
+	}
+}
+
+func (cq *CallbackQuery) answer(send func() (bool, error)) (bool, error) {
+	if !atomic.CompareAndSwapInt32(&cq.answered, 0, 1) {
+		return false, ErrCallbackQueryAnswered
+	}
+	ok, err := send()
+	if err != nil && !isQueryTooOld(err) {
+		// let it be answered again, as telegram didn't get the answer.
+		atomic.StoreInt32(&cq.answered, 0)
+	}
+	return ok, err
+}
+
+// isQueryTooOld reports whether telegram rejected an answer because the query had already been answered, or had
+// expired; telegram doesn't tell the two apart.
+func isQueryTooOld(err error) bool {
+	var tgErr *TelegramError
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Description, "query is too old")
 }
 
 type ForceReply struct {
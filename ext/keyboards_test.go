@@ -0,0 +1,90 @@
+package ext_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+)
+
+func TestCallbackQueryIsOnlyAnsweredOnce(t *testing.T) {
+	srv, b := newTestBot(t)
+	cq := &ext.CallbackQuery{Bot: b, Id: "1"}
+	if _, err := cq.AnswerText("hi", true); err != nil {
+		t.Fatal(err)
+	}
+	if !cq.Answered() {
+		t.Fatal("query isn't marked as answered")
+	}
+	if _, err := cq.AnswerURL("https://t.me/test_bot?start=x"); err != ext.ErrCallbackQueryAnswered {
+		t.Fatalf("expected ErrCallbackQueryAnswered, got %v", err)
+	}
+	if err := cq.EnsureAnswered(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.CallsTo("answerCallbackQuery")); n != 1 {
+		t.Fatalf("query was answered %d times", n)
+	}
+}
+
+func TestAnswersByIdAreTracked(t *testing.T) {
+	srv, b := newTestBot(t)
+	cq := &ext.CallbackQuery{Bot: b, Id: "1"}
+	release := cq.TrackAnswers()
+	if _, err := b.AnswerCallbackQueryText("1", "hi", false); err != nil {
+		t.Fatal(err)
+	}
+	if !cq.Answered() {
+		t.Fatal("query answered by id isn't marked as answered")
+	}
+	if err := cq.EnsureAnswered(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.CallsTo("answerCallbackQuery")); n != 1 {
+		t.Fatalf("query was answered %d times", n)
+	}
+
+	release()
+	other := &ext.CallbackQuery{Bot: b, Id: "2"}
+	if _, err := b.AnswerCallbackQuery("2"); err != nil {
+		t.Fatal(err)
+	}
+	if other.Answered() {
+		t.Fatal("untracked query was marked as answered")
+	}
+}
+
+func TestEnsureAnsweredIgnoresQueriesTooOld(t *testing.T) {
+	_, b := newTestBot(t)
+	if _, err := b.AnswerCallbackQuery("1"); err != nil {
+		t.Fatal(err)
+	}
+	// telegram rejects answers to a query answered elsewhere as too old.
+	cq := &ext.CallbackQuery{Bot: b, Id: "1"}
+	if err := cq.EnsureAnswered(); err != nil {
+		t.Fatalf("expected the too old query to be ignored, got %v", err)
+	}
+}
+
+func TestFailedAnswersCanBeRetried(t *testing.T) {
+	srv, b := newTestBot(t)
+	fail := true
+	srv.Handle("answerCallbackQuery", func(c gotgbottest.Call) (interface{}, error) {
+		if fail {
+			return nil, &ext.TelegramError{Code: http.StatusBadRequest, Description: "Bad Request: something went wrong"}
+		}
+		return true, nil
+	})
+	cq := &ext.CallbackQuery{Bot: b, Id: "1"}
+	if err := cq.EnsureAnswered(); err == nil {
+		t.Fatal("expected the answer to fail")
+	}
+	if cq.Answered() {
+		t.Fatal("failed answer marked the query as answered")
+	}
+	fail = false
+	if err := cq.EnsureAnswered(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -50,6 +51,11 @@ func (b Bot) NewSendableInvoice(chatId int, title string, description string, pa
 }
 
 func (i *sendableInvoice) Send() (*Message, error) {
+	return i.SendCtx(i.bot.Context())
+}
+
+func (i *sendableInvoice) SendCtx(ctx context.Context) (*Message, error) {
+	bot := i.bot.WithContext(ctx)
 	pricesStr, err := json.Marshal(i.Prices)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not marshal invoice prices")
@@ -87,15 +93,15 @@ func (i *sendableInvoice) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(i.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(i.bot, "sendInvoice", v)
+	r, err := Get(bot, "sendInvoice", v)
 	if err != nil {
-		return nil, errors.New(r.Description)
+		return nil, errors.Wrapf(err, "unable to sendInvoice")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendInvoice", r)
 	}
 
-	return i.bot.ParseMessage(r.Result), nil
+	return bot.ParseMessage(r.Result), nil
 }
 
 type sendableAnswerShippingQuery struct {
@@ -107,27 +113,34 @@ type sendableAnswerShippingQuery struct {
 }
 
 func (b Bot) NewSendableAnswerShippingQuery(shippingQueryId string, ok bool) *sendableAnswerShippingQuery {
-	return &sendableAnswerShippingQuery{ShippingQueryId: shippingQueryId, Ok: ok}
+	return &sendableAnswerShippingQuery{bot: b, ShippingQueryId: shippingQueryId, Ok: ok}
 }
 
 func (asq *sendableAnswerShippingQuery) Send() (bool, error) {
-	shippingOptions, err := json.Marshal(asq.ShippingOptions)
-	if err != nil {
-		return false, errors.Wrapf(err, "could not marshal shipping query shipping options")
-	}
+	return asq.SendCtx(asq.bot.Context())
+}
 
+func (asq *sendableAnswerShippingQuery) SendCtx(ctx context.Context) (bool, error) {
+	bot := asq.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("shipping_query_id", asq.ShippingQueryId)
 	v.Add("ok", strconv.FormatBool(asq.Ok))
-	v.Add("shipping_options", string(shippingOptions))
-	v.Add("error_message", asq.ErrorMessage)
+	if asq.Ok {
+		shippingOptions, err := json.Marshal(asq.ShippingOptions)
+		if err != nil {
+			return false, errors.Wrapf(err, "could not marshal shipping query shipping options")
+		}
+		v.Add("shipping_options", string(shippingOptions))
+	} else {
+		v.Add("error_message", asq.ErrorMessage)
+	}
 
-	r, err := Get(asq.bot, "answerShippingQuery", v)
+	r, err := Get(bot, "answerShippingQuery", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to answerShippingQuery")
 	}
 	if !r.Ok {
-		return false, errors.New("invalid answerShippingQuery")
+		return false, NewTelegramError("answerShippingQuery", r)
 	}
 
 	var bb bool
@@ -136,35 +149,35 @@ func (asq *sendableAnswerShippingQuery) Send() (bool, error) {
 }
 
 type sendableAnswerPreCheckoutQuery struct {
-	bot             Bot
-	ShippingQueryId string
-	Ok              bool
-	ShippingOptions []ShippingOption
-	ErrorMessage    string
+	bot                Bot
+	PreCheckoutQueryId string
+	Ok                 bool
+	ErrorMessage       string
 }
 
-func (b Bot) NewSendableAnswerPreCheckoutQuery(shippingQueryId string, ok bool) *sendableAnswerPreCheckoutQuery {
-	return &sendableAnswerPreCheckoutQuery{ShippingQueryId: shippingQueryId, Ok: ok}
+func (b Bot) NewSendableAnswerPreCheckoutQuery(preCheckoutQueryId string, ok bool) *sendableAnswerPreCheckoutQuery {
+	return &sendableAnswerPreCheckoutQuery{bot: b, PreCheckoutQueryId: preCheckoutQueryId, Ok: ok}
 }
 
 func (apcq *sendableAnswerPreCheckoutQuery) Send() (bool, error) {
-	shippingOptions, err := json.Marshal(apcq.ShippingOptions)
-	if err != nil {
-		return false, errors.Wrapf(err, "could not marshal pre checkout query shipping options")
-	}
+	return apcq.SendCtx(apcq.bot.Context())
+}
 
+func (apcq *sendableAnswerPreCheckoutQuery) SendCtx(ctx context.Context) (bool, error) {
+	bot := apcq.bot.WithContext(ctx)
 	v := url.Values{}
-	v.Add("shipping_query_id", apcq.ShippingQueryId)
+	v.Add("pre_checkout_query_id", apcq.PreCheckoutQueryId)
 	v.Add("ok", strconv.FormatBool(apcq.Ok))
-	v.Add("shipping_options", string(shippingOptions))
-	v.Add("error_message", apcq.ErrorMessage)
+	if !apcq.Ok {
+		v.Add("error_message", apcq.ErrorMessage)
+	}
 
-	r, err := Get(apcq.bot, "answerPreCheckoutQuery", v)
+	r, err := Get(bot, "answerPreCheckoutQuery", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to answerPreCheckoutQuery")
 	}
 	if !r.Ok {
-		return false, errors.New("invalid answerPreCheckoutQuery")
+		return false, NewTelegramError("answerPreCheckoutQuery", r)
 	}
 
 	var bb bool
@@ -46,6 +46,7 @@ type SuccessfulPayment struct {
 }
 
 type ShippingQuery struct {
+	Bot             Bot
 	Id              string          `json:"id"`
 	From            *User           `json:"from"`
 	InvoicePayload  string          `json:"invoice_payload"`
@@ -66,3 +67,29 @@ func (b Bot) AnswerShippingQuery(shippingQueryId string, ok bool) (bool, error)
 func (b Bot) AnswerPreCheckoutQuery(preCheckoutQueryId string, ok bool) (bool, error) {
 	return b.NewSendableAnswerPreCheckoutQuery(preCheckoutQueryId, ok).Send()
 }
+
+// Answer accepts the shipping address, and offers the given shipping options.
+func (sq ShippingQuery) Answer(options ...ShippingOption) (bool, error) {
+	a := sq.Bot.NewSendableAnswerShippingQuery(sq.Id, true)
+	a.ShippingOptions = options
+	return a.Send()
+}
+
+// AnswerError rejects the shipping address, showing the user the error message.
+func (sq ShippingQuery) AnswerError(errorMessage string) (bool, error) {
+	a := sq.Bot.NewSendableAnswerShippingQuery(sq.Id, false)
+	a.ErrorMessage = errorMessage
+	return a.Send()
+}
+
+// Answer confirms that the order can go ahead. It must be called within 10 seconds of receiving the query.
+func (pcq PreCheckoutQuery) Answer() (bool, error) {
+	return pcq.Bot.AnswerPreCheckoutQuery(pcq.Id, true)
+}
+
+// AnswerError cancels the order, showing the user the error message.
+func (pcq PreCheckoutQuery) AnswerError(errorMessage string) (bool, error) {
+	a := pcq.Bot.NewSendableAnswerPreCheckoutQuery(pcq.Id, false)
+	a.ErrorMessage = errorMessage
+	return a.Send()
+}
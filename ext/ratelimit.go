@@ -0,0 +1,197 @@
+package ext
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows a number of requests over a given period of time. A Limit with no Requests or no Per doesn't limit
+// anything.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimiter throttles outgoing messages to stay within telegram's broadcasting limits, using a token bucket for
+// each bot as a whole and one for each chat it sends to. Bots are told apart by their token, so one limiter can be
+// shared by many bots.
+type RateLimiter struct {
+	Global  Limit // applies to all messages sent by each bot
+	Private Limit // applies to messages sent by each bot to each private chat
+	Group   Limit // applies to messages sent by each bot to each group or channel
+
+	mu        sync.Mutex
+	bots      map[string]*bucket
+	chats     map[chatKey]*bucket
+	lastSweep time.Time
+}
+
+// chatKey identifies a chat being sent to by a bot.
+type chatKey struct {
+	token  string
+	chatId string
+}
+
+// NewRateLimiter returns a RateLimiter with telegram's documented limits: 30 messages per second overall,
+// 1 message per second per private chat, and 20 messages per minute per group.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		Global:  Limit{Requests: 30, Per: time.Second},
+		Private: Limit{Requests: 1, Per: time.Second},
+		Group:   Limit{Requests: 20, Per: time.Minute},
+		bots:    map[string]*bucket{},
+		chats:   map[chatKey]*bucket{},
+	}
+}
+
+// Wait blocks until the bot with the given token can send a message to the given chat without going over any limits,
+// or until ctx is done. An empty chatId only waits on the bot's global limit.
+func (rl *RateLimiter) Wait(ctx context.Context, token string, chatId string) error {
+	rl.mu.Lock()
+	now := time.Now()
+	var reserved []*bucket
+	if b := rl.botBucket(token, now); b != nil {
+		reserved = append(reserved, b)
+	}
+	if chatId != "" {
+		if b := rl.chatBucket(chatKey{token: token, chatId: chatId}, now); b != nil {
+			reserved = append(reserved, b)
+		}
+	}
+	var delay time.Duration
+	for _, b := range reserved {
+		if d := b.reserve(now); d > delay {
+			delay = d
+		}
+	}
+	rl.sweep(now)
+	rl.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleepCtx(ctx, delay); err != nil {
+		// the message won't be sent, so give back what was reserved.
+		rl.mu.Lock()
+		for _, b := range reserved {
+			b.tokens++
+		}
+		rl.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (rl *RateLimiter) botBucket(token string, now time.Time) *bucket {
+	if rl.bots == nil {
+		rl.bots = map[string]*bucket{}
+	}
+	b, ok := rl.bots[token]
+	if !ok {
+		b = newBucket(rl.Global, now)
+		if b == nil {
+			return nil
+		}
+		rl.bots[token] = b
+	}
+	return b
+}
+
+func (rl *RateLimiter) chatBucket(key chatKey, now time.Time) *bucket {
+	if rl.chats == nil {
+		rl.chats = map[chatKey]*bucket{}
+	}
+	b, ok := rl.chats[key]
+	if !ok {
+		// group and channel ids are negative; channels can also be addressed by @username.
+		limit := rl.Private
+		if strings.HasPrefix(key.chatId, "-") || strings.HasPrefix(key.chatId, "@") {
+			limit = rl.Group
+		}
+		b = newBucket(limit, now)
+		if b == nil {
+			return nil
+		}
+		rl.chats[key] = b
+	}
+	return b
+}
+
+// sweep drops the buckets of bots and chats which haven't been sent to recently, so that the maps don't grow forever.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < time.Minute {
+		return
+	}
+	rl.lastSweep = now
+	for token, b := range rl.bots {
+		if b.full(now) {
+			delete(rl.bots, token)
+		}
+	}
+	for key, b := range rl.chats {
+		if b.full(now) {
+			delete(rl.chats, key)
+		}
+	}
+}
+
+type bucket struct {
+	tokens float64
+	burst  float64
+	rate   float64 // tokens per second
+	last   time.Time
+}
+
+// newBucket returns a bucket for the given limit, or nil if the limit doesn't limit anything.
+func newBucket(l Limit, now time.Time) *bucket {
+	if l.Requests <= 0 || l.Per <= 0 {
+		return nil
+	}
+	return &bucket{
+		tokens: float64(l.Requests),
+		burst:  float64(l.Requests),
+		rate:   float64(l.Requests) / l.Per.Seconds(),
+		last:   now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// reserve takes a token from the bucket, and returns how long to wait before it can be used.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// isRateLimited returns whether a method sends a message, and therefore counts towards telegram's limits.
+func isRateLimited(method string) bool {
+	return method == "forwardMessage" || (strings.HasPrefix(method, "send") && method != "sendChatAction")
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
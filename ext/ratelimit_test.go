@@ -0,0 +1,135 @@
+package ext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBucketWaitsOnceEmpty(t *testing.T) {
+	now := time.Now()
+	b := newBucket(Limit{Requests: 2, Per: time.Second}, now)
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("first request waited %v", d)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("second request waited %v", d)
+	}
+	if d := b.reserve(now); d != 500*time.Millisecond {
+		t.Fatalf("third request waited %v", d)
+	}
+	// a quarter of a second refills half a token, leaving the next request one and a half tokens short.
+	if d := b.reserve(now.Add(250 * time.Millisecond)); d != 750*time.Millisecond {
+		t.Fatalf("fourth request waited %v", d)
+	}
+}
+
+func TestZeroLimitIsUnlimited(t *testing.T) {
+	for _, l := range []Limit{{}, {Requests: 1}, {Per: time.Second}} {
+		if b := newBucket(l, time.Now()); b != nil {
+			t.Fatalf("%+v made a bucket", l)
+		}
+	}
+
+	rl := &RateLimiter{Private: Limit{Requests: 1, Per: time.Hour}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 100; i++ {
+		if err := rl.Wait(ctx, "token", "-100"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rl.chats) != 0 {
+		t.Fatalf("kept buckets for unlimited chats: %v", rl.chats)
+	}
+	if err := rl.Wait(ctx, "token", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Wait(ctx, "token", "1"); err == nil {
+		t.Fatal("expected the private chat limit to apply")
+	}
+}
+
+func TestRateLimiterPicksLimitByChat(t *testing.T) {
+	rl := NewRateLimiter()
+	now := time.Now()
+	for id, want := range map[string]float64{"1": 1, "-1001": 20, "@channel": 20} {
+		if b := rl.chatBucket(chatKey{token: "token", chatId: id}, now); b.burst != want {
+			t.Fatalf("chat %s got burst %v, expected %v", id, b.burst, want)
+		}
+	}
+}
+
+func TestCancelledWaitGivesBackTokens(t *testing.T) {
+	rl := &RateLimiter{Global: Limit{Requests: 1, Per: time.Hour}}
+	if err := rl.Wait(context.Background(), "token", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rl.Wait(ctx, "token", ""); err == nil {
+		t.Fatal("expected the wait to be cancelled")
+	}
+	if b := rl.bots["token"]; b.tokens < -0.01 {
+		t.Fatalf("cancelled wait kept its token: %v", b.tokens)
+	}
+}
+
+func TestSweepDropsIdleChats(t *testing.T) {
+	rl := NewRateLimiter()
+	now := time.Now()
+	rl.botBucket("token", now).reserve(now)
+	rl.chatBucket(chatKey{token: "token", chatId: "1"}, now).reserve(now)
+	rl.chatBucket(chatKey{token: "token", chatId: "2"}, now).reserve(now)
+	rl.sweep(now.Add(time.Minute))
+	if len(rl.bots) != 0 || len(rl.chats) != 0 {
+		t.Fatalf("idle bots and chats were kept: %v %v", rl.bots, rl.chats)
+	}
+}
+
+func TestBotsSharingALimiterAreLimitedSeparately(t *testing.T) {
+	rl := &RateLimiter{Private: Limit{Requests: 1, Per: time.Hour}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := rl.Wait(ctx, "bot1", "1"); err != nil {
+		t.Fatal(err)
+	}
+	// the same chat id can be a different chat for another bot.
+	if err := rl.Wait(ctx, "bot2", "1"); err != nil {
+		t.Fatalf("second bot was limited by the first: %v", err)
+	}
+}
+
+func TestBotsSharingAGetterAreLimitedSeparately(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":true,"result":true}`)
+	}))
+	defer srv.Close()
+	tbg := &TgBotGetter{
+		Client:  srv.Client(),
+		ApiUrl:  srv.URL + "/bot",
+		Limiter: &RateLimiter{Global: Limit{Requests: 1, Per: time.Hour}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	bot1 := Bot{Token: "1:first", Logger: logrus.New(), Requester: tbg}.WithContext(ctx)
+	bot2 := Bot{Token: "2:second", Logger: logrus.New(), Requester: tbg}.WithContext(ctx)
+	params := url.Values{"chat_id": {"1"}}
+
+	if _, err := tbg.Get(bot1, "sendMessage", params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tbg.Get(bot2, "sendMessage", params); err != nil {
+		t.Fatalf("second bot was limited by the first: %v", err)
+	}
+	if _, err := tbg.Get(bot1, "sendMessage", params); err == nil {
+		t.Fatal("expected the first bot to still be limited")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -35,6 +36,11 @@ type Response struct {
 type TgBotGetter struct {
 	Client *http.Client
 	ApiUrl string
+	// MaxRetries is how many times a request is retried after telegram rejects it for flooding (error 429).
+	// Each retry first waits for as long as telegram's retry_after asks.
+	MaxRetries int
+	// Limiter, if set, delays outgoing messages to keep them within telegram's rate limits.
+	Limiter *RateLimiter
 }
 
 type TgBotGetterInterface interface {
@@ -42,22 +48,57 @@ type TgBotGetterInterface interface {
 	Post(bot Bot, fileType string, method string, params url.Values, file io.Reader, filename string) (*Response, error)
 }
 
+var _ TgBotGetterInterface = &TgBotGetter{}
+
+// WithMinTimeout returns a copy of the getter whose client waits at least the given duration for a response, eg for
+// long polling. The copy shares the client's transport, so connections are still pooled.
+func (tbg *TgBotGetter) WithMinTimeout(timeout time.Duration) *TgBotGetter {
+	cp := *tbg
+	client := http.Client{}
+	if tbg.Client != nil {
+		client = *tbg.Client
+	}
+	if client.Timeout != 0 && client.Timeout < timeout {
+		client.Timeout = timeout
+	}
+	cp.Client = &client
+	return &cp
+}
+
+// Get executes a GET request to the given method through the bot's Requester.
 func Get(bot Bot, method string, params url.Values) (*Response, error) {
-	return DefaultTgBotGetter.Get(bot, method, params)
+	return bot.requester().Get(bot, method, params)
 }
 
+// Post uploads a file to the given method through the bot's Requester.
 func Post(bot Bot, fileType string, method string, params url.Values, file io.Reader, filename string) (*Response, error) {
-	return DefaultTgBotGetter.Post(bot, fileType, method, params, file, filename)
+	return bot.requester().Post(bot, fileType, method, params, file, filename)
 }
 
 func (tbg *TgBotGetter) Get(bot Bot, method string, params url.Values) (*Response, error) {
+	for retries := 0; ; retries++ {
+		if err := tbg.wait(bot, method, params); err != nil {
+			return nil, errors.Wrapf(err, "unable to execute GET request to %v", method)
+		}
+		r, err := tbg.get(bot, method, params)
+		if !tbg.shouldRetry(r, err, retries) {
+			return r, err
+		}
+		if err := tbg.floodWait(bot, method, r); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (tbg *TgBotGetter) get(bot Bot, method string, params url.Values) (*Response, error) {
 	req, err := http.NewRequest("GET", tbg.ApiUrl+bot.Token+"/"+method, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to build GET request to %v", method)
 	}
+	req = req.WithContext(bot.Context())
 	req.URL.RawQuery = params.Encode()
 
-	bot.Logger.Debug("executing GET: %+v", req)
+	bot.Logger.Debugf("executing GET: %+v", req)
 	resp, err := tbg.Client.Do(req)
 	if err != nil {
 		bot.Logger.WithError(err).Debugf("failed to execute GET request to %v", method)
@@ -77,6 +118,36 @@ func (tbg *TgBotGetter) Get(bot Bot, method string, params url.Values) (*Respons
 }
 
 func (tbg *TgBotGetter) Post(bot Bot, fileType string, method string, params url.Values, file io.Reader, filename string) (*Response, error) {
+	if file == nil {
+		return nil, errors.Errorf("no file to upload in POST request to %v", method)
+	}
+	var data []byte
+	if tbg.MaxRetries > 0 {
+		// the file has to be read again for every retry, so keep it in memory.
+		var err error
+		data, err = ioutil.ReadAll(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read file for POST request to %v", method)
+		}
+	}
+	for retries := 0; ; retries++ {
+		if err := tbg.wait(bot, method, params); err != nil {
+			return nil, errors.Wrapf(err, "unable to execute POST request to %v", method)
+		}
+		if data != nil {
+			file = bytes.NewReader(data)
+		}
+		r, err := tbg.post(bot, fileType, method, params, file, filename)
+		if !tbg.shouldRetry(r, err, retries) {
+			return r, err
+		}
+		if err := tbg.floodWait(bot, method, r); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (tbg *TgBotGetter) post(bot Bot, fileType string, method string, params url.Values, file io.Reader, filename string) (*Response, error) {
 	if filename == "" {
 		filename = "unnamed_file"
 	}
@@ -101,11 +172,12 @@ func (tbg *TgBotGetter) Post(bot Bot, fileType string, method string, params url
 		bot.Logger.WithError(err).Debugf("failed to execute POST request to %v", method)
 		return nil, errors.Wrapf(err, "unable to execute POST request to %v", method)
 	}
+	req = req.WithContext(bot.Context())
 	req.URL.RawQuery = params.Encode()
 	req.Header.Set("Content-Type", w.FormDataContentType())
 
-	bot.Logger.Debug("POST request with body: %+v", b)
-	bot.Logger.Debug("executing POST: %+v", req)
+	bot.Logger.Debugf("POST request with body: %+v", b)
+	bot.Logger.Debugf("executing POST: %+v", req)
 	resp, err := tbg.Client.Do(req)
 	if err != nil {
 		return nil, err
@@ -115,10 +187,36 @@ func (tbg *TgBotGetter) Post(bot Bot, fileType string, method string, params url
 
 	var r Response
 	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		bot.Logger.WithError(err).Debug("failed to deserialize POST response body for %s", method)
+		bot.Logger.WithError(err).Debugf("failed to deserialize POST response body for %s", method)
 		return nil, errors.Wrapf(err, "could not decode in POST %v call", method)
 	}
 	bot.Logger.Debugf("received result: %+v", r)
 	bot.Logger.Debugf("result response: %v", string(r.Result))
 	return &r, nil
 }
+
+// wait blocks until the limiter allows the request to be sent.
+func (tbg *TgBotGetter) wait(bot Bot, method string, params url.Values) error {
+	if tbg.Limiter == nil || !isRateLimited(method) {
+		return nil
+	}
+	return tbg.Limiter.Wait(bot.Context(), bot.Token, params.Get("chat_id"))
+}
+
+// shouldRetry returns whether a request was rejected for flooding, and still has retries left.
+func (tbg *TgBotGetter) shouldRetry(r *Response, err error, retries int) bool {
+	return err == nil && !r.Ok && r.ErrorCode == http.StatusTooManyRequests && retries < tbg.MaxRetries
+}
+
+// floodWait sleeps for as long as telegram asked us to wait before retrying.
+func (tbg *TgBotGetter) floodWait(bot Bot, method string, r *Response) error {
+	delay := time.Second
+	if tgErr := NewTelegramError(method, r); tgErr.Params != nil && tgErr.Params.RetryAfter > 0 {
+		delay = time.Duration(tgErr.Params.RetryAfter) * time.Second
+	}
+	bot.Logger.Debugf("flood limit reached for %s, retrying in %v", method, delay)
+	if err := sleepCtx(bot.Context(), delay); err != nil {
+		return errors.Wrapf(err, "gave up retrying %v", method)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package ext_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/sirupsen/logrus"
+)
+
+// flood makes the server reject the first n calls to a method with error 429, and then send a message to the chat.
+func flood(srv *gotgbottest.Server, chat gotgbottest.Chat, method string, n int) {
+	calls := 0
+	srv.Handle(method, func(c gotgbottest.Call) (interface{}, error) {
+		if calls++; calls <= n {
+			return nil, &ext.TelegramError{
+				Code:        http.StatusTooManyRequests,
+				Description: "Too Many Requests: retry after 1",
+				Params:      &ext.ResponseParameters{RetryAfter: 1},
+			}
+		}
+		return &gotgbottest.Message{MessageId: calls, Chat: chat}, nil
+	})
+}
+
+func TestFloodErrorsAreRetried(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	r := srv.Requester()
+	r.MaxRetries = 1
+	b := ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: r}
+	chat := srv.PrivateChat(testUser)
+	flood(srv, chat, "sendMessage", 1)
+
+	start := time.Now()
+	if _, err := b.SendMessage(chat.Id, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < time.Second {
+		t.Fatal("retried without waiting for retry_after")
+	}
+	if n := len(srv.CallsTo("sendMessage")); n != 2 {
+		t.Fatalf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestFloodErrorIsReturnedWithoutRetries(t *testing.T) {
+	srv, b := newTestBot(t)
+	chat := srv.PrivateChat(testUser)
+	flood(srv, chat, "sendMessage", 1)
+
+	_, err := b.SendMessage(chat.Id, "hi")
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || tgErr.Code != http.StatusTooManyRequests || tgErr.Params.RetryAfter != 1 {
+		t.Fatalf("expected a flood error, got %v", err)
+	}
+}
+
+func TestPostRetriesResendTheFile(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	r := srv.Requester()
+	r.MaxRetries = 1
+	b := ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: r}
+	chat := srv.PrivateChat(testUser)
+	flood(srv, chat, "sendDocument", 1)
+
+	doc := b.NewSendableDocument(chat.Id, "")
+	doc.Reader = strings.NewReader("contents")
+	if _, err := doc.Send(); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.CallsTo("sendDocument")
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	for _, c := range calls {
+		if c.File == nil || string(c.File.Data) != "contents" {
+			t.Fatalf("file was not resent: %+v", c.File)
+		}
+	}
+}
+
+func TestPostWithoutFile(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	r := srv.Requester()
+	r.MaxRetries = 1
+	b := ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: r}
+	if _, err := ext.Post(b, "document", "sendDocument", nil, nil, ""); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+// recordingRequester records the methods called through it.
+type recordingRequester struct {
+	ext.TgBotGetterInterface
+	methods []string
+}
+
+func (r *recordingRequester) Get(bot ext.Bot, method string, params url.Values) (*ext.Response, error) {
+	r.methods = append(r.methods, method)
+	return r.TgBotGetterInterface.Get(bot, method, params)
+}
+
+func TestBotsUseTheirOwnRequester(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	r := &recordingRequester{TgBotGetterInterface: srv.Requester()}
+	b := ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: r}
+	chat := srv.PrivateChat(testUser)
+
+	msg, err := b.SendMessage(chat.Id, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// messages returned by the bot keep using its requester.
+	if _, err := msg.ReplyText("again"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.methods) != 2 || r.methods[0] != "sendMessage" || r.methods[1] != "sendMessage" {
+		t.Fatalf("unexpected calls %v", r.methods)
+	}
+	if len(srv.Messages(chat.Id)) != 2 {
+		t.Fatal("messages were not sent to the bot's server")
+	}
+}
@@ -46,7 +46,7 @@ func (b Bot) ForwardMessage(chatId int, fromChatId int, messageId int) (*Message
 		return nil, errors.Wrapf(err, "unable to forwardMessage")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("forwardMessage", r)
 	}
 	return b.ParseMessage(r.Result), nil
 }
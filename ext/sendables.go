@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
@@ -13,6 +14,7 @@ import (
 
 type Sendable interface {
 	Send() (*Message, error)
+	SendCtx(ctx context.Context) (*Message, error)
 }
 
 func (b Bot) NewSendableMessage(chatId int, text string) *sendableTextMessage {
@@ -238,6 +240,11 @@ type sendableTextMessage struct {
 }
 
 func (msg *sendableTextMessage) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableTextMessage) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -256,15 +263,15 @@ func (msg *sendableTextMessage) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "sendMessage", v)
+	r, err := Get(bot, "sendMessage", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendMessage")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendMessage", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -280,6 +287,11 @@ type sendableEditMessageText struct {
 }
 
 func (msg *sendableEditMessageText) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableEditMessageText) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -298,15 +310,15 @@ func (msg *sendableEditMessageText) Send() (*Message, error) {
 	v.Add("disable_web_page_preview", strconv.FormatBool(msg.DisableWebPreview))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "editMessageText", v)
+	r, err := Get(bot, "editMessageText", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to editMessageText")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("editMessageText", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -321,6 +333,11 @@ type sendableEditMessageCaption struct {
 }
 
 func (msg *sendableEditMessageCaption) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableEditMessageCaption) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -338,15 +355,15 @@ func (msg *sendableEditMessageCaption) Send() (*Message, error) {
 	v.Add("parse_mode", msg.ParseMode)
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "editMessageCaption", v)
+	r, err := Get(bot, "editMessageCaption", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to editMessageCaption")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("editMessageCaption", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -359,6 +376,11 @@ type sendableEditMessageReplyMarkup struct {
 }
 
 func (msg *sendableEditMessageReplyMarkup) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableEditMessageReplyMarkup) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -374,15 +396,15 @@ func (msg *sendableEditMessageReplyMarkup) Send() (*Message, error) {
 	v.Add("inline_message_id", msg.InlineMessageId)
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "editMessageCaption", v)
+	r, err := Get(bot, "editMessageReplyMarkup", v)
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to editMessageCaption")
+		return nil, errors.Wrapf(err, "unable to editMessageReplyMarkup")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("editMessageReplyMarkup", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -398,6 +420,11 @@ type sendablePhoto struct {
 }
 
 func (msg *sendablePhoto) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendablePhoto) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -415,15 +442,15 @@ func (msg *sendablePhoto) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "photo", "sendPhoto", v)
+	r, err := bot.sendFile(msg.file, "photo", "sendPhoto", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendPhoto")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendPhoto", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -442,6 +469,11 @@ type sendableAudio struct {
 }
 
 func (msg *sendableAudio) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableAudio) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -462,15 +494,15 @@ func (msg *sendableAudio) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "audio", "sendAudio", v)
+	r, err := bot.sendFile(msg.file, "audio", "sendAudio", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendAudio")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendAudio", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -487,6 +519,11 @@ type sendableDocument struct {
 }
 
 func (msg *sendableDocument) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableDocument) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -504,15 +541,15 @@ func (msg *sendableDocument) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "document", "sendDocument", v)
+	r, err := bot.sendFile(msg.file, "document", "sendDocument", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendDocument")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendDocument", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -532,6 +569,11 @@ type sendableVideo struct {
 }
 
 func (msg *sendableVideo) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableVideo) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -553,15 +595,15 @@ func (msg *sendableVideo) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "video", "sendVideo", v)
+	r, err := bot.sendFile(msg.file, "video", "sendVideo", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendVideo")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendVideo", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -578,6 +620,11 @@ type sendableVoice struct {
 }
 
 func (msg *sendableVoice) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableVoice) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -596,15 +643,15 @@ func (msg *sendableVoice) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "voice", "sendVoice", v)
+	r, err := bot.sendFile(msg.file, "voice", "sendVoice", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendVoice")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendVoice", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -620,6 +667,11 @@ type sendableVideoNote struct {
 }
 
 func (msg *sendableVideoNote) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableVideoNote) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -637,15 +689,15 @@ func (msg *sendableVideoNote) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "video", "sendVideoNote", v)
+	r, err := bot.sendFile(msg.file, "video", "sendVideoNote", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendVideoNote")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendVideoNote", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -659,6 +711,11 @@ type sendableEditMessageMedia struct {
 }
 
 func (msg *sendableEditMessageMedia) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableEditMessageMedia) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -679,15 +736,15 @@ func (msg *sendableEditMessageMedia) Send() (*Message, error) {
 	}
 	v.Add("media", string(vals))
 
-	r, err := Get(msg.bot, "editMessageMedia", v)
+	r, err := Get(bot, "editMessageMedia", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to editMessageMedia")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("editMessageMedia", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -702,6 +759,11 @@ type sendableMediaGroup struct {
 }
 
 func (msg *sendableMediaGroup) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableMediaGroup) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -719,15 +781,15 @@ func (msg *sendableMediaGroup) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "sendMediaGroup", v)
+	r, err := Get(bot, "sendMediaGroup", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendMediaGroup")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendMediaGroup", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -743,6 +805,11 @@ type sendableLocation struct {
 }
 
 func (msg *sendableLocation) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableLocation) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -761,15 +828,15 @@ func (msg *sendableLocation) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "sendLocation", v)
+	r, err := Get(bot, "sendLocation", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendLocation")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendLocation", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -790,6 +857,11 @@ type sendableVenue struct {
 }
 
 func (msg *sendableVenue) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableVenue) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -810,15 +882,15 @@ func (msg *sendableVenue) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "sendVenue", v)
+	r, err := Get(bot, "sendVenue", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendVenue")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendVenue", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -834,6 +906,11 @@ type sendableContact struct {
 }
 
 func (msg *sendableContact) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableContact) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -852,15 +929,15 @@ func (msg *sendableContact) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := Get(msg.bot, "sendContact", v)
+	r, err := Get(bot, "sendContact", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendContact")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendContact", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -871,19 +948,24 @@ type sendableChatAction struct {
 }
 
 func (msg *sendableChatAction) Send() (bool, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableChatAction) SendCtx(ctx context.Context) (bool, error) {
+	bot := msg.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("chat_id", strconv.Itoa(msg.ChatId))
 	v.Add("Action", msg.Action)
 
-	r, err := Get(msg.bot, "sendChatAction", v)
+	r, err := Get(bot, "sendChatAction", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to sendChatAction")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("sendChatAction", r)
 	}
-	var newMsg bool
-	return newMsg, json.Unmarshal(r.Result, newMsg)
+	var bb bool
+	return bb, json.Unmarshal(r.Result, &bb)
 }
 
 type sendableAnimation struct {
@@ -902,6 +984,11 @@ type sendableAnimation struct {
 }
 
 func (msg *sendableAnimation) Send() (*Message, error) {
+	return msg.SendCtx(msg.bot.Context())
+}
+
+func (msg *sendableAnimation) SendCtx(ctx context.Context) (*Message, error) {
+	bot := msg.bot.WithContext(ctx)
 	var replyMarkup []byte
 	if msg.ReplyMarkup != nil {
 		var err error
@@ -923,15 +1010,15 @@ func (msg *sendableAnimation) Send() (*Message, error) {
 	v.Add("reply_to_message_id", strconv.Itoa(msg.ReplyToMessageId))
 	v.Add("reply_markup", string(replyMarkup))
 
-	r, err := msg.bot.sendFile(msg.file, "animation", "sendAnimation", v)
+	r, err := bot.sendFile(msg.file, "animation", "sendAnimation", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendAnimation")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendAnimation", r)
 	}
 	newMsg := &Message{}
-	newMsg.Bot = msg.bot
+	newMsg.Bot = bot.detached()
 	return newMsg, json.Unmarshal(r.Result, newMsg)
 }
 
@@ -1,6 +1,7 @@
 package ext
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -29,6 +30,11 @@ func (b Bot) NewSendableSticker(chatId int) *sendableSticker {
 }
 
 func (s *sendableSticker) Send() (*Message, error) {
+	return s.SendCtx(s.bot.Context())
+}
+
+func (s *sendableSticker) SendCtx(ctx context.Context) (*Message, error) {
+	bot := s.bot.WithContext(ctx)
 	replyMarkup := []byte("{}")
 	if s.ReplyMarkup != nil {
 		var err error
@@ -48,15 +54,15 @@ func (s *sendableSticker) Send() (*Message, error) {
 		v.Add("reply_markup", string(replyMarkup))
 	}
 
-	r, err := s.bot.sendFile(s.file, "sticker", "sendSticker", v)
+	r, err := bot.sendFile(s.file, "sticker", "sendSticker", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to sendSticker")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("sendSticker", r)
 	}
 
-	return s.bot.ParseMessage(r.Result), nil
+	return bot.ParseMessage(r.Result), nil
 }
 
 type sendableUploadStickerFile struct {
@@ -70,18 +76,23 @@ func (b Bot) NewSendableUploadStickerFile(userId int) *sendableUploadStickerFile
 }
 
 func (usf *sendableUploadStickerFile) Send() (*File, error) {
+	return usf.SendCtx(usf.bot.Context())
+}
+
+func (usf *sendableUploadStickerFile) SendCtx(ctx context.Context) (*File, error) {
+	bot := usf.bot.WithContext(ctx)
 	v := url.Values{}
 	v.Add("user_id", strconv.Itoa(usf.UserId))
 
-	r, err := usf.bot.sendFile(usf.file, "sticker", "uploadStickerFile", v)
+	r, err := bot.sendFile(usf.file, "sticker", "uploadStickerFile", v)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to uploadStickerFile")
 	}
 	if !r.Ok {
-		return nil, errors.New(r.Description)
+		return nil, NewTelegramError("uploadStickerFile", r)
 	}
 	newFile := &File{}
-	newFile.bot = usf.bot
+	newFile.bot = bot
 	json.Unmarshal(r.Result, newFile)
 	return newFile, nil
 }
@@ -102,6 +113,11 @@ func (b Bot) NewSendableCreateNewSticker(userId int, name string, title string,
 }
 
 func (cns *sendableCreateNewSticker) Send() (bool, error) {
+	return cns.SendCtx(cns.bot.Context())
+}
+
+func (cns *sendableCreateNewSticker) SendCtx(ctx context.Context) (bool, error) {
+	bot := cns.bot.WithContext(ctx)
 	maskPos, err := json.Marshal(cns.MaskPosition)
 	if err != nil {
 		return false, errors.Wrapf(err, "failed to parse mask position")
@@ -115,12 +131,12 @@ func (cns *sendableCreateNewSticker) Send() (bool, error) {
 	v.Add("contains_mask", strconv.FormatBool(cns.ContainsMasks))
 	v.Add("mask_position", string(maskPos))
 
-	r, err := cns.bot.sendFile(cns.file, "sticker", "createNewStickerSet", v)
+	r, err := bot.sendFile(cns.file, "sticker", "createNewStickerSet", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to createNewStickerSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("createNewStickerSet", r)
 	}
 
 	var bb bool
@@ -143,6 +159,11 @@ func (b Bot) NewSendableAddStickerToSet(userId int, name string, emojis string)
 }
 
 func (asts *sendableAddStickerToSet) Send() (bool, error) {
+	return asts.SendCtx(asts.bot.Context())
+}
+
+func (asts *sendableAddStickerToSet) SendCtx(ctx context.Context) (bool, error) {
+	bot := asts.bot.WithContext(ctx)
 	maskPos, err := json.Marshal(asts.MaskPosition)
 	if err != nil {
 		return false, errors.Wrapf(err, "failed to parse mask position")
@@ -154,12 +175,12 @@ func (asts *sendableAddStickerToSet) Send() (bool, error) {
 	v.Add("emojis", asts.Emojis)
 	v.Add("mask_position", string(maskPos))
 
-	r, err := asts.bot.sendFile(asts.file, "sticker", "addStickerToSet", v)
+	r, err := bot.sendFile(asts.file, "sticker", "addStickerToSet", v)
 	if err != nil {
 		return false, errors.Wrapf(err, "unable to addStickerToSet")
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError("addStickerToSet", r)
 	}
 
 	var bb bool
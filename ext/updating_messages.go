@@ -92,7 +92,7 @@ func (b Bot) boolSender(meth string, v url.Values) (bb bool, err error) {
 		return false, errors.Wrapf(err, "unable to complete request for %s", meth)
 	}
 	if !r.Ok {
-		return false, errors.New(r.Description)
+		return false, NewTelegramError(meth, r)
 	}
 
 	return bb, json.Unmarshal(r.Result, &bb)
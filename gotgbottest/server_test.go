@@ -0,0 +1,216 @@
+package gotgbottest_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+var user = gotgbottest.User{Id: 42, FirstName: "Ann"}
+
+func newBot(srv *gotgbottest.Server) ext.Bot {
+	return ext.Bot{Token: gotgbottest.Token, Logger: logrus.New(), Requester: srv.Requester()}
+}
+
+func TestBotRepliesEndToEnd(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Bot.Id != srv.BotUser.Id || u.Bot.UserName != srv.BotUser.Username {
+		t.Fatalf("bot was not set up from getMe: %+v", u.Bot)
+	}
+	u.Dispatcher.AddHandler(handlers.NewCommand("start", func(b ext.Bot, upd *gotgbot.Update) error {
+		if _, err := upd.EffectiveMessage.ReplyText("hello " + upd.EffectiveUser.FirstName); err != nil {
+			return err
+		}
+		_, err := b.ReplyPhotoCaptionReader(upd.EffectiveChat.Id, strings.NewReader("data"), "cap", 0)
+		return err
+	}))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(user), user, "/start")
+	c, ok := srv.WaitForCall("sendPhoto", 2*time.Second)
+	if !ok {
+		t.Fatalf("bot never sent the photo: %+v", srv.Calls())
+	}
+	if c.File == nil || string(c.File.Data) != "data" {
+		t.Fatalf("unexpected upload %+v", c.File)
+	}
+	msgs := srv.Messages(user.Id)
+	if len(msgs) != 3 || msgs[1].Text != "hello Ann" || msgs[1].ReplyToMessage == nil || msgs[2].Caption != "cap" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+}
+
+func TestUnknownChatsAreRejected(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	_, err := newBot(srv).SendMessage(999, "hi")
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || tgErr.Description != "Bad Request: chat not found" {
+		t.Fatalf("expected chat not found, got %v", err)
+	}
+}
+
+func TestWrongTokenIsUnauthorized(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	b := newBot(srv)
+	b.Token = "1:WRONG"
+	_, err := b.GetMe()
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || tgErr.Code != 401 {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestHandleOverridesMethods(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	srv.Handle("getChat", func(c gotgbottest.Call) (interface{}, error) {
+		return nil, errors.New("chat is gone")
+	})
+	_, err := newBot(srv).GetChat(user.Id)
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || tgErr.Code != 400 || tgErr.Description != "Bad Request: chat is gone" {
+		t.Fatalf("expected the handler's error, got %v", err)
+	}
+}
+
+func TestHandlerErrorsWithoutACodeAreBadRequests(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	srv.Handle("getChat", func(c gotgbottest.Call) (interface{}, error) {
+		return nil, &ext.TelegramError{Description: "Bad Request: chat is gone"}
+	})
+	_, err := newBot(srv).GetChat(user.Id)
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || tgErr.Code != 400 || tgErr.Description != "Bad Request: chat is gone" {
+		t.Fatalf("expected a bad request, got %v", err)
+	}
+}
+
+func TestUploadedFilesCanBeFetched(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	b := newBot(srv)
+	chat := srv.PrivateChat(user)
+	doc := b.NewSendableDocument(chat.Id, "")
+	doc.Reader = strings.NewReader("contents")
+	msg, err := doc.Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := b.GetFile(msg.Document.FileId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FileSize != len("contents") {
+		t.Fatalf("unexpected file %+v", f)
+	}
+}
+
+func TestMessagesAreCopies(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	chat := srv.PrivateChat(user)
+	sent := srv.SendMessage(chat, user, "hi")
+	sent.Text = "changed"
+	srv.Messages(chat.Id)[0].Text = "changed"
+	if text := srv.Messages(chat.Id)[0].Text; text != "hi" {
+		t.Fatalf("stored message was changed to %q", text)
+	}
+
+	edited := srv.EditMessage(sent, "edited")
+	if edited.Text != "edited" || edited.EditDate == 0 || sent.Text != "changed" {
+		t.Fatalf("unexpected edit %+v of %+v", edited, sent)
+	}
+	if text := srv.Messages(chat.Id)[0].Text; text != "edited" {
+		t.Fatalf("edit was not stored: %q", text)
+	}
+}
+
+// TestConcurrentEdits is mostly useful with -race: the test reads and edits a message while the bot edits it.
+func TestConcurrentEdits(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	b := newBot(srv)
+	chat := srv.PrivateChat(user)
+	msg, err := b.SendMessage(chat.Id, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := srv.Messages(chat.Id)[0]
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if stored.Text == "" {
+				t.Error("message has no text")
+			}
+			srv.EditMessage(stored, "from the test")
+			srv.PressButton(user, stored, "data")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := b.EditMessageText(chat.Id, msg.MessageId, "from the bot"); err != nil {
+				t.Error(err)
+				return
+			}
+			srv.Messages(chat.Id)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestDeletedMessagesAreGone(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	b := newBot(srv)
+	chat := srv.PrivateChat(user)
+	msg, err := b.SendMessage(chat.Id, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.DeleteMessage(chat.Id, msg.MessageId); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Messages(chat.Id)) != 0 {
+		t.Fatal("message was not deleted")
+	}
+	if _, err := b.EditMessageText(chat.Id, msg.MessageId, "edit"); err == nil {
+		t.Fatal("expected editing a deleted message to fail")
+	}
+}
+
+func TestCallbackQueriesCanOnlyBeAnsweredOnce(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	b := newBot(srv)
+	msg := srv.SendMessage(srv.PrivateChat(user), user, "hi")
+	q := srv.PressButton(user, msg, "data")
+	if _, err := b.AnswerCallbackQuery(q.Id); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.AnswerCallbackQueryText(q.Id, "again", false)
+	var tgErr *ext.TelegramError
+	if !errors.As(err, &tgErr) || !strings.Contains(tgErr.Description, "query is too old") {
+		t.Fatalf("expected the second answer to be rejected, got %v", err)
+	}
+}
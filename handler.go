@@ -1,7 +1,9 @@
 package gotgbot
 
 import (
+	"context"
 	"encoding/json"
+	"regexp"
 
 	"github.com/PaulSonOfLars/gotgbot/ext"
 )
@@ -18,7 +20,7 @@ type Update struct {
 	EditedMessage      *ext.Message            `json:"edited_message"`
 	ChannelPost        *ext.Message            `json:"channel_post"`
 	EditedChannelPost  *ext.Message            `json:"edited_channel_post"`
-	InlineQuery        *ext.Message            `json:"inline_query"`
+	InlineQuery        *ext.InlineQuery        `json:"inline_query"`
 	ChosenInlineResult *ext.ChosenInlineResult `json:"chosen_inline_result"`
 	CallbackQuery      *ext.CallbackQuery      `json:"callback_query"`
 	ShippingQuery      *ext.ShippingQuery      `json:"shipping_query"`
@@ -29,6 +31,57 @@ type Update struct {
 	EffectiveChat    *ext.Chat    `json:"effective_chat"`
 	EffectiveUser    *ext.User    `json:"effective_user"`
 	Data             map[string]string
+	// Match holds the capture groups found by the handler handling the update, if that handler matches a regex.
+	Match *RegexMatch
+	// UserData, ChatData and BotData are kept across updates, and stored by the dispatcher's Persistence.
+	// UserData and ChatData are nil if the update has no user or chat.
+	UserData *Storage
+	ChatData *Storage
+	BotData  *Storage
+
+	ctx context.Context
+}
+
+// RegexMatch holds the result of matching a handler's regex against an update.
+type RegexMatch struct {
+	// Groups holds the text of the whole match, followed by that of each capture group.
+	Groups []string
+	// Named holds the text of each named capture group.
+	Named map[string]string
+}
+
+// NewRegexMatch matches the regex against the text; it returns nil if it doesn't match.
+func NewRegexMatch(re *regexp.Regexp, text string) *RegexMatch {
+	groups := re.FindStringSubmatch(text)
+	if groups == nil {
+		return nil
+	}
+	m := &RegexMatch{Groups: groups, Named: map[string]string{}}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			m.Named[name] = groups[i]
+		}
+	}
+	return m
+}
+
+// Group returns the text of the i-th capture group, where 0 is the whole match, or "" if there is no such group.
+func (m *RegexMatch) Group(i int) string {
+	if m == nil || i < 0 || i >= len(m.Groups) {
+		return ""
+	}
+	return m.Groups[i]
+}
+
+// Context returns the context the update is being handled under. It is cancelled once the dispatcher's
+// UpdateTimeout has passed, when the dispatcher is forced to shut down, or once the update has been handled.
+// To have API calls aborted with it, pass it to them explicitly, eg with b.WithContext(u.Context()) or SendCtx; the
+// messages they return aren't bound to it, so they can still be used after the handler returns.
+func (u *Update) Context() context.Context {
+	if u.ctx != nil {
+		return u.ctx
+	}
+	return context.Background()
 }
 
 // todo: move this into dispatcher update processor to updater CPU cycles
@@ -54,7 +107,6 @@ func initUpdate(data RawUpdate, bot ext.Bot) *Update {
 		upd.EffectiveChat = upd.EditedChannelPost.Chat
 
 	} else if upd.InlineQuery != nil {
-		upd.EffectiveMessage = upd.InlineQuery
 		upd.EffectiveUser = upd.InlineQuery.From
 
 	} else if upd.CallbackQuery != nil && upd.CallbackQuery.Message != nil {
@@ -72,21 +124,41 @@ func initUpdate(data RawUpdate, bot ext.Bot) *Update {
 		upd.EffectiveUser = upd.PreCheckoutQuery.From
 	}
 
-	if upd.EffectiveMessage != nil {
-		upd.EffectiveMessage.Bot = bot
-		if upd.EffectiveMessage.ReplyToMessage != nil {
-			upd.EffectiveMessage.ReplyToMessage.Bot = bot
-			if upd.EffectiveMessage.ReplyToMessage.From != nil {
-				upd.EffectiveMessage.ReplyToMessage.From.Bot = bot
+	upd.setBot(bot)
+	upd.Data = make(map[string]string)
+	return &upd
+}
+
+// setBot sets the bot used by the update's effective message, chat and user.
+func (u *Update) setBot(bot ext.Bot) {
+	if u.EffectiveMessage != nil {
+		u.EffectiveMessage.Bot = bot
+		if u.EffectiveMessage.ReplyToMessage != nil {
+			u.EffectiveMessage.ReplyToMessage.Bot = bot
+			if u.EffectiveMessage.ReplyToMessage.From != nil {
+				u.EffectiveMessage.ReplyToMessage.From.Bot = bot
 			}
 		}
 	}
-	if upd.EffectiveChat != nil {
-		upd.EffectiveChat.Bot = bot
+	if u.EffectiveChat != nil {
+		u.EffectiveChat.Bot = bot
 	}
-	if upd.EffectiveUser != nil {
-		upd.EffectiveUser.Bot = bot
+	if u.EffectiveUser != nil {
+		u.EffectiveUser.Bot = bot
+	}
+	if u.CallbackQuery != nil {
+		u.CallbackQuery.Bot = bot
+	}
+	if u.InlineQuery != nil {
+		u.InlineQuery.Bot = bot
+	}
+	if u.ChosenInlineResult != nil {
+		u.ChosenInlineResult.Bot = bot
+	}
+	if u.ShippingQuery != nil {
+		u.ShippingQuery.Bot = bot
+	}
+	if u.PreCheckoutQuery != nil {
+		u.PreCheckoutQuery.Bot = bot
 	}
-	upd.Data = make(map[string]string)
-	return &upd
 }
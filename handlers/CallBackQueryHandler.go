@@ -5,39 +5,45 @@ import (
 
 	"github.com/PaulSonOfLars/gotgbot"
 	"github.com/PaulSonOfLars/gotgbot/ext"
-	"github.com/pkg/errors"
 )
 
 type CallBack struct {
 	baseHandler
-	Pattern  string
+	// Pattern is matched against the callback data; if nil, all callback queries match. The capture groups are
+	// stored in the update's Match.
+	Pattern  *regexp.Regexp
 	Response func(b ext.Bot, u *gotgbot.Update) error
+	// AutoAnswer answers the callback query once the response returns, unless the response has answered it through
+	// the query's Answer methods.
+	AutoAnswer bool
 }
 
-func NewCallback(pattern string, response func(b ext.Bot, u *gotgbot.Update) error) CallBack {
+// NewCallback creates a handler for callback queries whose data matches the pattern; an empty pattern matches all
+// callback queries.
+func NewCallback(pattern string, response func(b ext.Bot, u *gotgbot.Update) error) (CallBack, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return CallBack{}, err
+	}
 	return CallBack{
 		baseHandler: baseHandler{
 			Name: pattern,
 		},
-		Pattern:  pattern,
+		Pattern:  re,
 		Response: response,
-	}
+	}, nil
 }
 
 func (cb CallBack) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
-	return cb.Response(d.Bot, u)
+	if cb.Pattern != nil {
+		u.Match = gotgbot.NewRegexMatch(cb.Pattern, u.CallbackQuery.Data)
+	}
+	return autoAnswer(cb.AutoAnswer, u, cb.Response(d.Bot, u))
 }
 
 func (cb CallBack) CheckUpdate(u *gotgbot.Update) (bool, error) {
 	if u.CallbackQuery == nil {
 		return false, nil
 	}
-	if cb.Pattern != "" {
-		res, err := regexp.MatchString(cb.Pattern, u.CallbackQuery.Data)
-		if err != nil {
-			return false, errors.Wrapf(err, "Could not match regexp")
-		}
-		return res, nil
-	}
-	return true, nil
+	return cb.Pattern == nil || cb.Pattern.MatchString(u.CallbackQuery.Data), nil
 }
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestCallbackCaptureGroups(t *testing.T) {
+	if _, err := handlers.NewCallback("(", nil); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+	got := make(chan string, 2)
+	h, err := handlers.NewCallback(`^page:(?P<page>\d+)$`, func(b ext.Bot, u *gotgbot.Update) error {
+		got <- u.Match.Named["page"] + "/" + u.Match.Group(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, h)
+
+	m := srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	srv.PressButton(testUser, m, "other:4")
+	srv.PressButton(testUser, m, "page:4")
+	select {
+	case s := <-got:
+		if s != "4/4" {
+			t.Fatalf("unexpected match %q", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback query wasn't handled")
+	}
+	select {
+	case s := <-got:
+		t.Fatalf("unmatched callback query was handled: %q", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCallbackAutoAnswer(t *testing.T) {
+	errs := make(chan error, 3)
+	answers := map[string]func(u *gotgbot.Update) error{
+		"none": func(u *gotgbot.Update) error { return nil },
+		"text": func(u *gotgbot.Update) error {
+			_, err := u.CallbackQuery.AnswerText("done", false)
+			return err
+		},
+		"id": func(u *gotgbot.Update) error {
+			_, err := u.EffectiveUser.Bot.AnswerCallbackQueryText(u.CallbackQuery.Id, "done", false)
+			return err
+		},
+	}
+	h := handlers.CallBack{AutoAnswer: true, Response: func(b ext.Bot, u *gotgbot.Update) error {
+		return answers[u.CallbackQuery.Data](u)
+	}}
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Dispatcher.AddHandler(h)
+	u.Dispatcher.UseGroup(0, func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			err := next(u, d)
+			if u.CallbackQuery != nil {
+				errs <- err
+			}
+			return err
+		}
+	})
+	u.StartPolling()
+	defer u.Stop()
+
+	m := srv.SendMessage(srv.PrivateChat(testUser), testUser, "buttons")
+	for data := range answers {
+		srv.ResetCalls()
+		q := srv.PressButton(testUser, m, data)
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("%s: %v", data, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: query wasn't handled", data)
+		}
+		var answered []string
+		for _, c := range srv.CallsTo("answerCallbackQuery") {
+			if c.Params.Get("callback_query_id") == q.Id {
+				answered = append(answered, c.Params.Get("text"))
+			}
+		}
+		if len(answered) != 1 || data != "none" && answered[0] != "done" {
+			t.Fatalf("%s: unexpected answers %q", data, answered)
+		}
+		if data == "text" && len(answered) != 1 {
+			t.Fatalf("%s: query was answered again: %q", data, answered)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+)
+
+// MaxCallbackDataLength is the maximum number of bytes telegram allows in a button's callback data.
+const MaxCallbackDataLength = 64
+
+// CallbackParams holds the parameters parsed from callback data, by name.
+type CallbackParams map[string]string
+
+func (p CallbackParams) String(name string) string {
+	return p[name]
+}
+
+func (p CallbackParams) Int(name string) (int, error) {
+	i, err := strconv.Atoi(p[name])
+	return i, errors.Wrapf(err, "callback parameter %s is not an int", name)
+}
+
+func (p CallbackParams) Int64(name string) (int64, error) {
+	i, err := strconv.ParseInt(p[name], 10, 64)
+	return i, errors.Wrapf(err, "callback parameter %s is not an int64", name)
+}
+
+func (p CallbackParams) Bool(name string) (bool, error) {
+	b, err := strconv.ParseBool(p[name])
+	return b, errors.Wrapf(err, "callback parameter %s is not a bool", name)
+}
+
+// CallbackRoute is a callback data template, such as "vote:{poll_id}:{option}", and the response to callback
+// queries whose data matches it.
+type CallbackRoute struct {
+	Template string
+	Response func(b ext.Bot, u *gotgbot.Update, params CallbackParams) error
+	names    []string
+	literals []string // the text around the params; there is always one more literal than there are params
+	re       *regexp.Regexp
+}
+
+var templateParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// NewCallbackRoute parses the template. Params are written as {name}, and must be separated by some text, so that
+// the data can be parsed again.
+func NewCallbackRoute(template string, response func(b ext.Bot, u *gotgbot.Update, params CallbackParams) error) (*CallbackRoute, error) {
+	r := &CallbackRoute{Template: template, Response: response}
+	pattern := strings.Builder{}
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range templateParam.FindAllStringSubmatchIndex(template, -1) {
+		literal := template[last:loc[0]]
+		if len(r.names) > 0 && literal == "" {
+			return nil, errors.Errorf("callback route %q has params which aren't separated", template)
+		}
+		name := template[loc[2]:loc[3]]
+		for _, n := range r.names {
+			if n == name {
+				return nil, errors.Errorf("callback route %q has param %s more than once", template, name)
+			}
+		}
+		r.literals = append(r.literals, literal)
+		r.names = append(r.names, name)
+		pattern.WriteString(regexp.QuoteMeta(literal) + "(.*?)")
+		last = loc[1]
+	}
+	r.literals = append(r.literals, template[last:])
+	pattern.WriteString(regexp.QuoteMeta(template[last:]) + "$")
+	if strings.ContainsAny(strings.Join(r.literals, ""), "{}") {
+		return nil, errors.Errorf("callback route %q has an invalid param", template)
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not compile callback route %q", template)
+	}
+	r.re = re
+	return r, nil
+}
+
+// Parse returns the params in the data, if it matches the route.
+func (r *CallbackRoute) Parse(data string) (CallbackParams, bool) {
+	groups := r.re.FindStringSubmatch(data)
+	if groups == nil {
+		return nil, false
+	}
+	params := make(CallbackParams, len(r.names))
+	for i, name := range r.names {
+		params[name] = groups[i+1]
+	}
+	return params, true
+}
+
+// Data fills in the route's params with the values, in the order they appear in the template. It fails if the data
+// would be longer than telegram allows, or if a value contains text which would stop it from being parsed again.
+func (r *CallbackRoute) Data(values ...interface{}) (string, error) {
+	if len(values) != len(r.names) {
+		return "", errors.Errorf("callback route %q needs %d values, got %d", r.Template, len(r.names), len(values))
+	}
+	data := strings.Builder{}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = fmt.Sprint(v)
+		data.WriteString(r.literals[i] + strs[i])
+	}
+	data.WriteString(r.literals[len(values)])
+
+	s := data.String()
+	if len(s) > MaxCallbackDataLength {
+		return "", errors.Errorf("callback data %q is %d bytes long, over telegram's limit of %d", s, len(s), MaxCallbackDataLength)
+	}
+	params, ok := r.Parse(s)
+	for i, name := range r.names {
+		if !ok || params[name] != strs[i] {
+			return "", errors.Errorf("callback data %q can't be parsed by route %q; value %q is ambiguous", s, r.Template, strs[i])
+		}
+	}
+	return s, nil
+}
+
+// Button returns an inline keyboard button with the given text, whose callback data is filled in with the values.
+func (r *CallbackRoute) Button(text string, values ...interface{}) (ext.InlineKeyboardButton, error) {
+	data, err := r.Data(values...)
+	if err != nil {
+		return ext.InlineKeyboardButton{}, err
+	}
+	return ext.InlineKeyboardButton{Text: text, CallbackData: data}, nil
+}
+
+// CallbackRouter handles callback queries with the first of its routes which matches the callback data.
+type CallbackRouter struct {
+	baseHandler
+	// AutoAnswer answers the callback query once the route's response returns, unless the response has answered it.
+	AutoAnswer bool
+	routes     *[]*CallbackRoute
+}
+
+func NewCallbackRouter(name string) CallbackRouter {
+	return CallbackRouter{
+		baseHandler: baseHandler{
+			Name: name,
+		},
+		routes: &[]*CallbackRoute{},
+	}
+}
+
+// Handle adds a route to the router, and returns it so that it can be used to create buttons.
+func (cr CallbackRouter) Handle(template string, response func(b ext.Bot, u *gotgbot.Update, params CallbackParams) error) (*CallbackRoute, error) {
+	r, err := NewCallbackRoute(template, response)
+	if err != nil {
+		return nil, err
+	}
+	*cr.routes = append(*cr.routes, r)
+	return r, nil
+}
+
+func (cr CallbackRouter) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	r, params := cr.route(u)
+	if r == nil {
+		return nil
+	}
+	return autoAnswer(cr.AutoAnswer, u, r.Response(d.Bot, u, params))
+}
+
+func (cr CallbackRouter) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	r, _ := cr.route(u)
+	return r != nil, nil
+}
+
+func (cr CallbackRouter) route(u *gotgbot.Update) (*CallbackRoute, CallbackParams) {
+	if u.CallbackQuery == nil {
+		return nil, nil
+	}
+	for _, r := range *cr.routes {
+		if params, ok := r.Parse(u.CallbackQuery.Data); ok {
+			return r, params
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestCallbackRouteTemplates(t *testing.T) {
+	for _, template := range []string{"x:{a}{b}", "x:{a}:{a}", "x:{a-b}", "x:{"} {
+		if _, err := handlers.NewCallbackRoute(template, nil); err == nil {
+			t.Errorf("expected %q to be rejected", template)
+		}
+	}
+	r, err := handlers.NewCallbackRoute("vote:{poll_id}:{option}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for data, want := range map[string]handlers.CallbackParams{
+		"vote:1:yes":   {"poll_id": "1", "option": "yes"},
+		"vote:1:a:b":   {"poll_id": "1", "option": "a:b"},
+		"vote::":       {"poll_id": "", "option": ""},
+		"vote:1":       nil,
+		"other:1:yes":  nil,
+		"vote:1:yes ∞": {"poll_id": "1", "option": "yes ∞"},
+	} {
+		params, ok := r.Parse(data)
+		if ok != (want != nil) || params["poll_id"] != want["poll_id"] || params["option"] != want["option"] {
+			t.Errorf("%q: expected %v, got %v", data, want, params)
+		}
+	}
+}
+
+func TestCallbackRouteData(t *testing.T) {
+	r, err := handlers.NewCallbackRoute("vote:{poll_id}:{option}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := r.Data(1, "a:b"); err != nil || data != "vote:1:a:b" {
+		t.Fatalf("unexpected data %q, %v", data, err)
+	}
+	if _, err := r.Data(1); err == nil {
+		t.Error("expected a missing value to be rejected")
+	}
+	// poll_id would be parsed as "1", and option as "2:b".
+	if _, err := r.Data("1:2", "b"); err == nil {
+		t.Error("expected an ambiguous value to be rejected")
+	}
+
+	// the limit is in bytes, not characters.
+	prefix := len("vote:1:")
+	if _, err := r.Data(1, strings.Repeat("y", handlers.MaxCallbackDataLength-prefix)); err != nil {
+		t.Errorf("data of exactly %d bytes was rejected: %v", handlers.MaxCallbackDataLength, err)
+	}
+	if _, err := r.Data(1, strings.Repeat("y", handlers.MaxCallbackDataLength-prefix+1)); err == nil {
+		t.Error("expected data over the limit to be rejected")
+	}
+	if _, err := r.Data(1, strings.Repeat("é", (handlers.MaxCallbackDataLength-prefix)/2+1)); err == nil {
+		t.Error("expected multi-byte data over the limit to be rejected")
+	}
+	if _, err := r.Button("yes", 1, strings.Repeat("y", 100)); err == nil {
+		t.Error("expected a button with data over the limit to be rejected")
+	}
+}
+
+func TestCallbackRouterRoutes(t *testing.T) {
+	got := make(chan string, 2)
+	router := handlers.NewCallbackRouter("router")
+	vote, err := router.Handle("vote:{poll_id}:{option}", func(b ext.Bot, u *gotgbot.Update, params handlers.CallbackParams) error {
+		id, err := params.Int("poll_id")
+		if err != nil {
+			return err
+		}
+		got <- strings.Repeat("#", id) + params.String("option")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.Handle("page:{n}", func(b ext.Bot, u *gotgbot.Update, params handlers.CallbackParams) error {
+		got <- "page " + params.String("n")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	btn, err := vote.Button("Yes", 3, "yes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, router)
+
+	m := srv.SendMessage(srv.PrivateChat(testUser), testUser, "poll")
+	srv.PressButton(testUser, m, "other")
+	srv.PressButton(testUser, m, btn.CallbackData)
+	srv.PressButton(testUser, m, "page:2")
+	want := map[string]bool{"###yes": true, "page 2": true}
+	for len(want) > 0 {
+		select {
+		case s := <-got:
+			if !want[s] {
+				t.Fatalf("unexpected response %q", s)
+			}
+			delete(want, s)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("routes weren't called: %v", want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+type ChosenInlineResult struct {
+	baseHandler
+	// Pattern is matched against the id of the chosen result; if nil, all chosen results match.
+	Pattern *regexp.Regexp
+	// Filter, if set, must also accept the chosen result.
+	Filter   func(r *ext.ChosenInlineResult) bool
+	Response func(b ext.Bot, u *gotgbot.Update) error
+}
+
+// NewChosenInlineResult creates a handler for chosen inline results whose id matches the pattern; an empty pattern
+// matches all chosen results.
+func NewChosenInlineResult(pattern string, response func(b ext.Bot, u *gotgbot.Update) error) (ChosenInlineResult, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return ChosenInlineResult{}, err
+	}
+	return ChosenInlineResult{
+		baseHandler: baseHandler{
+			Name: pattern,
+		},
+		Pattern:  re,
+		Response: response,
+	}, nil
+}
+
+func (h ChosenInlineResult) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	return h.Response(d.Bot, u)
+}
+
+func (h ChosenInlineResult) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	if u.ChosenInlineResult == nil {
+		return false, nil
+	}
+	if h.Pattern != nil && !h.Pattern.MatchString(u.ChosenInlineResult.ResultId) {
+		return false, nil
+	}
+	return h.Filter == nil || h.Filter(u.ChosenInlineResult), nil
+}
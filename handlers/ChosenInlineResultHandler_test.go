@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestChosenInlineResultPattern(t *testing.T) {
+	chosen := make(chan string, 2)
+	h, err := handlers.NewChosenInlineResult("^item-", func(b ext.Bot, u *gotgbot.Update) error {
+		chosen <- u.ChosenInlineResult.ResultId + " by " + u.EffectiveUser.FirstName
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, h)
+
+	srv.ChooseInlineResult(testUser, "find", "other-1")
+	srv.ChooseInlineResult(testUser, "find", "item-1")
+	select {
+	case got := <-chosen:
+		if got != "item-1 by Ann" {
+			t.Fatalf("unexpected result %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("chosen result wasn't handled")
+	}
+	select {
+	case got := <-chosen:
+		t.Fatalf("unmatched result %q was handled", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
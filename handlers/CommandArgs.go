@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+)
+
+// ArgType is the type a command argument is converted to.
+type ArgType int
+
+const (
+	ArgString ArgType = iota
+	ArgInt
+	// ArgDuration accepts anything time.ParseDuration does, as well as whole days and weeks, such as "2d" or "1w".
+	ArgDuration
+	// ArgUser accepts a text mention, an @username or a user id.
+	ArgUser
+	// ArgChat accepts a chat id.
+	ArgChat
+	// ArgBool accepts true/false, yes/no and on/off. Flags of this type are switches, which take no value.
+	ArgBool
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "number"
+	case ArgDuration:
+		return "duration"
+	case ArgUser:
+		return "user"
+	case ArgChat:
+		return "chat id"
+	case ArgBool:
+		return "yes/no"
+	default:
+		return "text"
+	}
+}
+
+// Arg is a positional command argument.
+type Arg struct {
+	Name        string
+	Type        ArgType
+	Description string
+	// Optional args may be left out; they must come after all required args.
+	Optional bool
+	// Variadic collects all remaining arguments; it must be the last arg.
+	Variadic bool
+}
+
+// Flag is an argument given by name, as "--name", "--name value" or "--name=value"; a value starting with "--" must be
+// quoted or given with "=". It can appear anywhere after the command. A "--" stops any later arguments from being
+// read as flags.
+type Flag struct {
+	Name        string
+	Type        ArgType
+	Description string
+}
+
+// ArgSpec describes the arguments a command takes.
+type ArgSpec struct {
+	Args  []Arg
+	Flags []Flag
+}
+
+// Validate checks that the spec can be parsed unambiguously.
+func (s ArgSpec) Validate() error {
+	names := map[string]bool{}
+	optional := false
+	for i, a := range s.Args {
+		if a.Name == "" || names[a.Name] {
+			return errors.Errorf("invalid or repeated arg name %q", a.Name)
+		}
+		names[a.Name] = true
+		if a.Variadic && i != len(s.Args)-1 {
+			return errors.Errorf("variadic arg %s must be the last arg", a.Name)
+		}
+		if optional && !a.Optional {
+			return errors.Errorf("required arg %s comes after an optional arg", a.Name)
+		}
+		optional = optional || a.Optional
+	}
+	for _, f := range s.Flags {
+		if f.Name == "" || names[f.Name] {
+			return errors.Errorf("invalid or repeated flag name %q", f.Name)
+		}
+		names[f.Name] = true
+	}
+	return nil
+}
+
+// Usage describes how to call the command, starting with a line such as "/ban <user> [duration] [reason...] [--silent]",
+// followed by a line for each described arg and flag.
+func (s ArgSpec) Usage(command string) string {
+	usage := strings.Builder{}
+	usage.WriteString(command)
+	for _, a := range s.Args {
+		name := a.Name
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			usage.WriteString(" [" + name + "]")
+		} else {
+			usage.WriteString(" <" + name + ">")
+		}
+	}
+	for _, f := range s.Flags {
+		usage.WriteString(" [" + flagUsage(f) + "]")
+	}
+
+	for _, a := range s.Args {
+		if a.Description != "" {
+			usage.WriteString(fmt.Sprintf("\n  %s (%s): %s", a.Name, a.Type, a.Description))
+		}
+	}
+	for _, f := range s.Flags {
+		if f.Description != "" {
+			usage.WriteString(fmt.Sprintf("\n  %s: %s", flagUsage(f), f.Description))
+		}
+	}
+	return usage.String()
+}
+
+func flagUsage(f Flag) string {
+	if f.Type == ArgBool {
+		return "--" + f.Name
+	}
+	return "--" + f.Name + " <" + f.Type.String() + ">"
+}
+
+// CommandArgs holds the arguments parsed from a command, converted to their types. The getters return the zero
+// value if the argument wasn't given, or is of another type.
+type CommandArgs struct {
+	// Raw is the text after the command, as it was sent.
+	Raw    string
+	values map[string]interface{}
+}
+
+// Get returns the value of an arg or flag. Variadic args are returned as a []interface{}.
+func (a *CommandArgs) Get(name string) (interface{}, bool) {
+	v, ok := a.values[name]
+	return v, ok
+}
+
+// Has reports whether the arg or flag was given.
+func (a *CommandArgs) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+func (a *CommandArgs) String(name string) string {
+	s, _ := a.values[name].(string)
+	return s
+}
+
+func (a *CommandArgs) Int(name string) int {
+	i, _ := a.values[name].(int)
+	return i
+}
+
+func (a *CommandArgs) Duration(name string) time.Duration {
+	d, _ := a.values[name].(time.Duration)
+	return d
+}
+
+// User returns the user given as an arg. Only text mentions contain the whole user; otherwise, only the Id or the
+// Username is set.
+func (a *CommandArgs) User(name string) *ext.User {
+	u, _ := a.values[name].(*ext.User)
+	return u
+}
+
+// Chat returns the chat id given as an arg.
+func (a *CommandArgs) Chat(name string) int {
+	return a.Int(name)
+}
+
+// Bool returns the value of a bool arg, or whether a switch flag was given.
+func (a *CommandArgs) Bool(name string) bool {
+	b, _ := a.values[name].(bool)
+	return b
+}
+
+// List returns the values of a variadic arg.
+func (a *CommandArgs) List(name string) []interface{} {
+	l, _ := a.values[name].([]interface{})
+	return l
+}
+
+// Strings returns the values of a variadic string arg.
+func (a *CommandArgs) Strings(name string) []string {
+	var out []string
+	for _, v := range a.List(name) {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Parse reads the arguments after the command at the start of the message's text.
+//
+// Arguments are split on spaces, unless quoted with "double" or 'single' quotes, or escaped with a backslash. Quotes
+// are only recognised at the start of an argument, and only if they are closed at the end of one, so that apostrophes
+// such as in "don't" or "'tis" are kept. Text mentions are always read as a single argument, even if the user's name
+// contains spaces.
+//
+// The errors returned are meant to be shown to the user.
+func (s ArgSpec) Parse(msg *ext.Message) (*CommandArgs, error) {
+	text := msg.Text
+	start := len(text)
+	if fields := strings.Fields(text); len(fields) > 0 {
+		start = strings.Index(text, fields[0]) + len(fields[0])
+	}
+	args := &CommandArgs{
+		Raw:    strings.TrimSpace(text[start:]),
+		values: map[string]interface{}{},
+	}
+
+	mentions := map[int]ext.ParsedMessageEntity{}
+	for _, ent := range msg.ParseEntityTypes(map[string]struct{}{"text_mention": {}}) {
+		mentions[ent.Offset] = ent
+	}
+	tokens := splitArgs(text, start, mentions)
+
+	var positional []argToken
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if !tok.isFlag() {
+			positional = append(positional, tok)
+			continue
+		}
+		if tok.text == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+
+		name := strings.TrimPrefix(tok.text, "--")
+		value, hasValue := "", false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		f, ok := s.flag(name)
+		if !ok {
+			return nil, errors.Errorf("unknown flag --%s", name)
+		}
+		if f.Type == ArgBool && !hasValue {
+			args.values[f.Name] = true
+			continue
+		}
+		valueTok := argToken{text: value}
+		if !hasValue {
+			if i+1 == len(tokens) || tokens[i+1].isFlag() {
+				return nil, errors.Errorf("flag --%s needs a %s", f.Name, f.Type)
+			}
+			i++
+			valueTok = tokens[i]
+		}
+		v, err := convertArg(valueTok, f.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --%s", f.Name)
+		}
+		args.values[f.Name] = v
+	}
+
+	for _, a := range s.Args {
+		if a.Variadic {
+			if len(positional) == 0 && !a.Optional {
+				return nil, errors.Errorf("missing %s", a.Name)
+			}
+			list := make([]interface{}, 0, len(positional))
+			for _, tok := range positional {
+				v, err := convertArg(tok, a.Type)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid %s", a.Name)
+				}
+				list = append(list, v)
+			}
+			if len(list) > 0 {
+				args.values[a.Name] = list
+			}
+			positional = nil
+			break
+		}
+		if len(positional) == 0 {
+			if !a.Optional {
+				return nil, errors.Errorf("missing %s", a.Name)
+			}
+			break
+		}
+		v, err := convertArg(positional[0], a.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", a.Name)
+		}
+		args.values[a.Name] = v
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, errors.Errorf("unexpected argument %q", positional[0].text)
+	}
+	return args, nil
+}
+
+func (s ArgSpec) flag(name string) (Flag, bool) {
+	for _, f := range s.Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+type argToken struct {
+	text   string
+	quoted bool      // quoted or escaped, so never a flag
+	user   *ext.User // set for text mentions
+}
+
+// isFlag reports whether the token is a flag, or the "--" which ends them.
+func (t argToken) isFlag() bool {
+	return !t.quoted && t.user == nil && strings.HasPrefix(t.text, "--")
+}
+
+// splitArgs splits the text from start into arguments. mentions holds the text mentions in the text, by offset.
+func splitArgs(text string, start int, mentions map[int]ext.ParsedMessageEntity) []argToken {
+	var tokens []argToken
+	i := start
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		if ent, ok := mentions[i]; ok {
+			tokens = append(tokens, argToken{text: ent.Text, user: ent.User})
+			i += ent.Length
+			continue
+		}
+
+		tok := argToken{}
+		buf := strings.Builder{}
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+			switch {
+			case r == '\\' && i < len(text):
+				r, size = utf8.DecodeRuneInString(text[i:])
+				i += size
+				buf.WriteRune(r)
+				tok.quoted = true
+			case buf.Len() == 0 && !tok.quoted && isOpeningQuote(r):
+				quoted := strings.Builder{}
+				end, ok := closeQuote(text, i, r, &quoted)
+				if !ok {
+					// an apostrophe, rather than a quote.
+					buf.WriteRune(r)
+					continue
+				}
+				buf.WriteString(quoted.String())
+				i = end
+				tok.quoted = true
+			default:
+				buf.WriteRune(r)
+			}
+		}
+		tok.text = buf.String()
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// isOpeningQuote includes the curly quote some clients replace " with.
+func isOpeningQuote(r rune) bool {
+	return r == '"' || r == '\'' || r == '“'
+}
+
+// closeQuote writes the quoted text starting at i to buf, and returns the index after the closing quote, which must
+// end an argument. Backslashes escape quotes and backslashes within double quotes.
+func closeQuote(text string, i int, open rune, buf *strings.Builder) (int, bool) {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		next, nextSize := utf8.DecodeRuneInString(text[i:])
+		closing := open == '\'' && r == '\'' || open != '\'' && (r == '"' || r == '”')
+		switch {
+		case closing && (i == len(text) || unicode.IsSpace(next)):
+			return i, true
+		case open != '\'' && r == '\\' && i < len(text):
+			if next == '"' || next == '\\' || next == '”' {
+				r = next
+				i += nextSize
+			}
+		}
+		buf.WriteRune(r)
+	}
+	return i, false
+}
+
+func convertArg(tok argToken, t ArgType) (interface{}, error) {
+	switch t {
+	case ArgInt, ArgChat:
+		i, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a %s", tok.text, t)
+		}
+		return i, nil
+	case ArgDuration:
+		d, err := parseDuration(tok.text)
+		if err != nil {
+			return nil, errors.Errorf("%q is not a duration, such as 30m or 2d", tok.text)
+		}
+		return d, nil
+	case ArgUser:
+		if tok.user != nil {
+			return tok.user, nil
+		}
+		if strings.HasPrefix(tok.text, "@") && len(tok.text) > 1 {
+			return &ext.User{Username: tok.text[1:]}, nil
+		}
+		if id, err := strconv.Atoi(tok.text); err == nil {
+			return &ext.User{Id: id}, nil
+		}
+		return nil, errors.Errorf("%q is not a user", tok.text)
+	case ArgBool:
+		switch strings.ToLower(tok.text) {
+		case "true", "yes", "y", "on", "1":
+			return true, nil
+		case "false", "no", "n", "off", "0":
+			return false, nil
+		}
+		return nil, errors.Errorf("%q is not yes or no", tok.text)
+	default:
+		return tok.text, nil
+	}
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, errors.Errorf("invalid duration %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil {
+		return 0, errors.Errorf("invalid duration %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
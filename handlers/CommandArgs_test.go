@@ -0,0 +1,181 @@
+package handlers_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+var banSpec = handlers.ArgSpec{
+	Args: []handlers.Arg{
+		{Name: "user", Type: handlers.ArgUser, Description: "who to ban"},
+		{Name: "for", Type: handlers.ArgDuration, Optional: true},
+		{Name: "reason", Variadic: true, Optional: true},
+	},
+	Flags: []handlers.Flag{
+		{Name: "silent", Type: handlers.ArgBool, Description: "don't announce the ban"},
+		{Name: "chat", Type: handlers.ArgChat},
+	},
+}
+
+// utf16Len is the length of the text in the UTF-16 code units telegram counts entity offsets in.
+func utf16Len(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+func TestArgSpecValidate(t *testing.T) {
+	for name, spec := range map[string]handlers.ArgSpec{
+		"repeated arg":                     {Args: []handlers.Arg{{Name: "a"}, {Name: "a"}}},
+		"arg and flag with the same name":  {Args: []handlers.Arg{{Name: "a"}}, Flags: []handlers.Flag{{Name: "a"}}},
+		"unnamed arg":                      {Args: []handlers.Arg{{}}},
+		"variadic before another arg":      {Args: []handlers.Arg{{Name: "a", Variadic: true}, {Name: "b"}}},
+		"required after optional":          {Args: []handlers.Arg{{Name: "a", Optional: true}, {Name: "b"}}},
+		"required variadic after optional": {Args: []handlers.Arg{{Name: "a", Optional: true}, {Name: "b", Variadic: true}}},
+	} {
+		if err := spec.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := handlers.NewParsedCommand("x", spec, nil); err == nil {
+			t.Errorf("%s: expected the command to be rejected", name)
+		}
+	}
+	if err := banSpec.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArgSpecParse(t *testing.T) {
+	name := "Jöhn Smith"
+	text := `/ban@test_bot  Jöhn Smith 2d "being rude" don't --silent --chat=-100 -- --again`
+	msg := &ext.Message{Text: text, Entities: []ext.MessageEntity{
+		{Type: "bot_command", Offset: 0, Length: utf16Len("/ban@test_bot")},
+		{Type: "text_mention", Offset: utf16Len("/ban@test_bot  "), Length: utf16Len(name), User: &ext.User{Id: 42, FirstName: name}},
+	}}
+	args, err := banSpec.Parse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.User("user").Id != 42 || args.Duration("for") != 48*time.Hour || !args.Bool("silent") || args.Chat("chat") != -100 {
+		t.Fatalf("unexpected args %+v", args)
+	}
+	if reason := args.Strings("reason"); !reflect.DeepEqual(reason, []string{"being rude", "don't", "--again"}) {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+	if args.Raw != strings.TrimPrefix(text, "/ban@test_bot  ") {
+		t.Fatalf("unexpected raw text %q", args.Raw)
+	}
+
+	args, err = banSpec.Parse(&ext.Message{Text: `/ban 7 1h30m “a \"b” c\ d`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.User("user").Id != 7 || args.Duration("for") != 90*time.Minute || args.Has("silent") {
+		t.Fatalf("unexpected args %+v", args)
+	}
+	if reason := args.Strings("reason"); !reflect.DeepEqual(reason, []string{`a "b`, "c d"}) {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+}
+
+func TestArgSpecQuotes(t *testing.T) {
+	spec := handlers.ArgSpec{Args: []handlers.Arg{{Name: "words", Variadic: true}}}
+	for text, want := range map[string][]string{
+		`/x 'tis the season`:                    {"'tis", "the", "season"},
+		`/x 'tis fine, isn't it`:                {"'tis", "fine,", "isn't", "it"},
+		`/x "open`:                              {`"open`},
+		`/x 'two words' and "three more words"`: {"two words", "and", "three more words"},
+		`/x 'it's' here`:                        {"it's", "here"},
+		`/x "" empty`:                           {"", "empty"},
+	} {
+		args, err := spec.Parse(&ext.Message{Text: text})
+		if err != nil {
+			t.Errorf("%s: %v", text, err)
+			continue
+		}
+		if got := args.Strings("words"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %q, got %q", text, want, got)
+		}
+	}
+}
+
+func TestArgSpecErrors(t *testing.T) {
+	for text, want := range map[string]string{
+		"/ban":                      "missing user",
+		"/ban @ann --nope":          "unknown flag --nope",
+		"/ban ann":                  `invalid user: "ann" is not a user`,
+		"/ban @ann --chat":          "flag --chat needs a chat id",
+		"/ban @ann --chat --silent": "flag --chat needs a chat id",
+		"/ban @ann --chat=x":        `invalid --chat: "x" is not a chat id`,
+		"/ban @ann 5q":              `invalid for: "5q" is not a duration, such as 30m or 2d`,
+		"/ban @ann --silent=maybe":  `invalid --silent: "maybe" is not yes or no`,
+	} {
+		_, err := banSpec.Parse(&ext.Message{Text: text})
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, got %v", text, want, err)
+		}
+	}
+	spec := handlers.ArgSpec{Args: []handlers.Arg{{Name: "n", Type: handlers.ArgInt}}}
+	if _, err := spec.Parse(&ext.Message{Text: "/x 1 2"}); err == nil || err.Error() != `unexpected argument "2"` {
+		t.Errorf("expected an unexpected argument, got %v", err)
+	}
+	// flag values starting with -- can still be given with = or quotes.
+	spec = handlers.ArgSpec{Flags: []handlers.Flag{{Name: "sep"}}}
+	for _, text := range []string{"/x --sep=--", `/x --sep "--"`} {
+		args, err := spec.Parse(&ext.Message{Text: text})
+		if err != nil || args.String("sep") != "--" {
+			t.Errorf("%s: unexpected value %q, %v", text, args.String("sep"), err)
+		}
+	}
+}
+
+func TestArgSpecUsage(t *testing.T) {
+	want := "/ban <user> [for] [reason...] [--silent] [--chat <chat id>]\n" +
+		"  user (user): who to ban\n" +
+		"  --silent: don't announce the ban"
+	if usage := banSpec.Usage("/ban"); usage != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, usage)
+	}
+}
+
+func TestParsedCommand(t *testing.T) {
+	sums := make(chan int, 1)
+	cmd, err := handlers.NewParsedCommand("add", handlers.ArgSpec{Args: []handlers.Arg{{Name: "nums", Type: handlers.ArgInt, Variadic: true}}},
+		func(b ext.Bot, u *gotgbot.Update, args *handlers.CommandArgs) error {
+			sum := 0
+			for _, n := range args.List("nums") {
+				sum += n.(int)
+			}
+			sums <- sum
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, cmd)
+
+	chat := srv.PrivateChat(testUser)
+	srv.SendMessage(chat, testUser, "/add 1 2 3")
+	select {
+	case sum := <-sums:
+		if sum != 6 {
+			t.Fatalf("expected 6, got %d", sum)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command wasn't handled")
+	}
+
+	srv.SendMessage(chat, testUser, "/add 1 x")
+	c, ok := srv.WaitForCall("sendMessage", 2*time.Second)
+	if !ok {
+		t.Fatal("usage wasn't sent")
+	}
+	if text := c.Params.Get("text"); text != "invalid nums: \"x\" is not a number\n\nUsage: /add <nums...>" {
+		t.Fatalf("unexpected usage %q", text)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/PaulSonOfLars/gotgbot"
 	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
 )
 
 type baseCommand struct {
@@ -13,6 +14,13 @@ type baseCommand struct {
 	AllowEdited  bool
 	AllowChannel bool
 	Command      string
+	// Description is shown in the bot's command list and in help messages.
+	Description string
+	// Scopes limits where the command is listed; if empty, it is listed everywhere.
+	Scopes []ext.BotCommandScope
+	// Hidden leaves the command out of the command list and help messages. Commands which can't be triggered with
+	// "/" are always hidden.
+	Hidden bool
 }
 
 type Command struct {
@@ -25,6 +33,14 @@ type ArgsCommand struct {
 	Response func(b ext.Bot, u *gotgbot.Update, args []string) error
 }
 
+// ParsedCommand parses its arguments according to Spec before calling Response. If they can't be parsed, it replies
+// with the error and the command's usage instead.
+type ParsedCommand struct {
+	baseCommand
+	Spec     ArgSpec
+	Response func(b ext.Bot, u *gotgbot.Update, args *CommandArgs) error
+}
+
 func NewPrefixCommand(command string, prefixes []rune, response func(b ext.Bot, u *gotgbot.Update) error) Command {
 	cmd := strings.ToLower(command)
 	return Command{
@@ -65,6 +81,30 @@ func NewArgsCommand(command string, response func(b ext.Bot, u *gotgbot.Update,
 	return NewPrefixArgsCommand(command, []rune("/"), response)
 }
 
+func NewPrefixParsedCommand(command string, prefixes []rune, spec ArgSpec, response func(b ext.Bot, u *gotgbot.Update, args *CommandArgs) error) (ParsedCommand, error) {
+	if err := spec.Validate(); err != nil {
+		return ParsedCommand{}, errors.Wrapf(err, "invalid args for command %s", command)
+	}
+	cmd := strings.ToLower(command)
+	return ParsedCommand{
+		baseCommand: baseCommand{
+			baseHandler: baseHandler{
+				Name: cmd,
+			},
+			Triggers:     prefixes,
+			AllowEdited:  false,
+			AllowChannel: false,
+			Command:      cmd,
+		},
+		Spec:     spec,
+		Response: response,
+	}, nil
+}
+
+func NewParsedCommand(command string, spec ArgSpec, response func(b ext.Bot, u *gotgbot.Update, args *CommandArgs) error) (ParsedCommand, error) {
+	return NewPrefixParsedCommand(command, []rune("/"), spec, response)
+}
+
 func (h Command) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
 	return h.Response(d.Bot, u)
 }
@@ -73,6 +113,35 @@ func (h ArgsCommand) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error
 	return h.Response(d.Bot, u, strings.Fields(u.EffectiveMessage.Text)[1:])
 }
 
+func (h ParsedCommand) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	args, err := h.Spec.Parse(u.EffectiveMessage)
+	if err != nil {
+		_, err = u.EffectiveMessage.ReplyText(err.Error() + "\n\nUsage: " + h.Spec.Usage(h.usagePrefix()+h.Command))
+		return errors.Wrapf(err, "failed to send usage of command %s", h.Command)
+	}
+	return h.Response(d.Bot, u, args)
+}
+
+func (h baseCommand) usagePrefix() string {
+	if len(h.Triggers) == 0 {
+		return ""
+	}
+	return string(h.Triggers[0])
+}
+
+func (h baseCommand) CommandInfo() gotgbot.CommandInfo {
+	slash := false
+	for _, t := range h.Triggers {
+		slash = slash || t == '/'
+	}
+	return gotgbot.CommandInfo{
+		Command:     h.Command,
+		Description: h.Description,
+		Scopes:      h.Scopes,
+		Hidden:      h.Hidden || !slash,
+	}
+}
+
 // todo optimise if statements?
 func (h baseCommand) CheckUpdate(u *gotgbot.Update) (bool, error) {
 	if u.EffectiveMessage == nil || u.EffectiveMessage.Text == "" {
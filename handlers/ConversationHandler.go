@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/pkg/errors"
+)
+
+// NextState is returned by a conversation's handlers to move the conversation into the named state.
+type NextState string
+
+// EndConversation is returned by a conversation's handlers to end the conversation.
+type EndConversation struct{}
+
+func (s NextState) Error() string        { return "Conversation moved to state " + string(s) }
+func (ec EndConversation) Error() string { return "Conversation ended" }
+
+// KeyStrategy determines what a conversation is tied to.
+type KeyStrategy int
+
+const (
+	// KeySenderAndChat has a separate conversation for each user in each chat.
+	KeySenderAndChat KeyStrategy = iota
+	// KeySender has a single conversation per user, whichever chat they're in.
+	KeySender
+	// KeyChat has a single conversation per chat, shared by all its users.
+	KeyChat
+)
+
+type Conversation struct {
+	baseHandler
+	// EntryPoints start the conversation; they should return a NextState.
+	EntryPoints []gotgbot.Handler
+	// States maps each state to the handlers which can be used in that state.
+	States map[string][]gotgbot.Handler
+	// Fallbacks are checked when none of the current state's handlers match.
+	Fallbacks []gotgbot.Handler
+	// AllowReEntry lets the entry points restart a conversation which is already ongoing.
+	AllowReEntry bool
+	KeyStrategy  KeyStrategy
+	// Timeouts ends conversations which have stayed in a state for longer than the given duration. A conversation
+	// which times out simply ends: no handler is called, and the next update is handled as if the conversation had
+	// never started.
+	Timeouts      map[string]time.Duration
+	conversations *conversations
+}
+
+func NewConversation(name string, entryPoints []gotgbot.Handler, states map[string][]gotgbot.Handler, fallbacks []gotgbot.Handler) Conversation {
+	return Conversation{
+		baseHandler: baseHandler{
+			Name: name,
+		},
+		EntryPoints:   entryPoints,
+		States:        states,
+		Fallbacks:     fallbacks,
+		AllowReEntry:  false,
+		KeyStrategy:   KeySenderAndChat,
+		Timeouts:      map[string]time.Duration{},
+		conversations: &conversations{states: map[string]conversationState{}},
+	}
+}
+
+func (c Conversation) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	key, ok := c.key(u)
+	if !ok {
+		return nil
+	}
+	next, err := c.nextHandler(key, u)
+	if err != nil || next == nil {
+		return err
+	}
+
+	err = next.HandleUpdate(u, d)
+	switch e := err.(type) {
+	case NextState:
+		if _, ok := c.States[string(e)]; !ok {
+			return errors.Errorf("conversation %s has no state %q", c.Name, string(e))
+		}
+		return c.store().set(key, string(e), c.Timeouts[string(e)])
+	case EndConversation:
+		return c.store().delete(key)
+	default:
+		return err
+	}
+}
+
+func (c Conversation) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	key, ok := c.key(u)
+	if !ok {
+		return false, nil
+	}
+	next, err := c.nextHandler(key, u)
+	return next != nil, err
+}
+
+// ListedHandlers returns the conversation's entry points, so that the commands which start it are listed.
+func (c Conversation) ListedHandlers() []gotgbot.Handler {
+	return c.EntryPoints
+}
+
+// SetPersistence restores the conversations stored in p, and stores all state changes in it from then on.
+// Restored conversations get a fresh timeout.
+func (c Conversation) SetPersistence(p gotgbot.Persistence) error {
+	states, err := p.GetConversations(c.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load conversation %s", c.Name)
+	}
+	c.store().load(c.Name, p, states, c.Timeouts)
+	return nil
+}
+
+// CurrentState returns the state of the conversation an update belongs to, if there is one.
+func (c Conversation) CurrentState(u *gotgbot.Update) (string, bool) {
+	key, ok := c.key(u)
+	if !ok {
+		return "", false
+	}
+	return c.store().get(key)
+}
+
+// nextHandler returns the handler which should handle the update, or nil if the conversation isn't interested.
+func (c Conversation) nextHandler(key string, u *gotgbot.Update) (gotgbot.Handler, error) {
+	state, ongoing := c.store().get(key)
+	if !ongoing || c.AllowReEntry {
+		if h, err := checkHandlers(c.EntryPoints, u); h != nil || err != nil {
+			return h, err
+		}
+		if !ongoing {
+			return nil, nil
+		}
+	}
+	if h, err := checkHandlers(c.States[state], u); h != nil || err != nil {
+		return h, err
+	}
+	return checkHandlers(c.Fallbacks, u)
+}
+
+// unmanaged keeps the state of conversations which weren't created with NewConversation, by name.
+var unmanaged = struct {
+	sync.Mutex
+	byName map[string]*conversations
+}{byName: map[string]*conversations{}}
+
+// store returns where the conversation's state is kept. Conversations which weren't created with NewConversation
+// share theirs with any other such conversation of the same name.
+func (c Conversation) store() *conversations {
+	if c.conversations != nil {
+		return c.conversations
+	}
+	unmanaged.Lock()
+	defer unmanaged.Unlock()
+	cs, ok := unmanaged.byName[c.Name]
+	if !ok {
+		cs = &conversations{states: map[string]conversationState{}}
+		unmanaged.byName[c.Name] = cs
+	}
+	return cs
+}
+
+func (c Conversation) key(u *gotgbot.Update) (string, bool) {
+	switch c.KeyStrategy {
+	case KeySender:
+		if u.EffectiveUser == nil {
+			return "", false
+		}
+		return strconv.Itoa(u.EffectiveUser.Id), true
+	case KeyChat:
+		if u.EffectiveChat == nil {
+			return "", false
+		}
+		return strconv.Itoa(u.EffectiveChat.Id), true
+	default:
+		if u.EffectiveUser == nil || u.EffectiveChat == nil {
+			return "", false
+		}
+		return strconv.Itoa(u.EffectiveChat.Id) + ":" + strconv.Itoa(u.EffectiveUser.Id), true
+	}
+}
+
+// checkHandlers returns the first handler which accepts the update.
+func checkHandlers(handlers []gotgbot.Handler, u *gotgbot.Update) (gotgbot.Handler, error) {
+	for _, h := range handlers {
+		ok, err := h.CheckUpdate(u)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+type conversationState struct {
+	State   string
+	Expires time.Time // zero if the state never expires
+}
+
+// conversations stores the current state of every ongoing conversation.
+type conversations struct {
+	mu          sync.Mutex
+	states      map[string]conversationState
+	name        string
+	persistence gotgbot.Persistence
+}
+
+func (cs *conversations) load(name string, p gotgbot.Persistence, states map[string]string, timeouts map[string]time.Duration) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.name = name
+	cs.persistence = p
+	cs.states = map[string]conversationState{}
+	for key, state := range states {
+		cs.states[key] = newConversationState(state, timeouts[state])
+	}
+}
+
+func newConversationState(state string, timeout time.Duration) conversationState {
+	s := conversationState{State: state}
+	if timeout > 0 {
+		s.Expires = time.Now().Add(timeout)
+	}
+	return s
+}
+
+func (cs *conversations) get(key string) (string, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	s, ok := cs.states[key]
+	if !ok {
+		return "", false
+	}
+	if !s.Expires.IsZero() && time.Now().After(s.Expires) {
+		delete(cs.states, key)
+		// an expired conversation is still expired if this fails, it'll just be restored after a restart.
+		cs.store(key, "")
+		return "", false
+	}
+	return s.State, true
+}
+
+func (cs *conversations) set(key string, state string, timeout time.Duration) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.states[key] = newConversationState(state, timeout)
+	return cs.store(key, state)
+}
+
+func (cs *conversations) delete(key string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	delete(cs.states, key)
+	return cs.store(key, "")
+}
+
+// store writes a state change to the persistence, if there is one. It must be called with the lock held.
+func (cs *conversations) store(key string, state string) error {
+	if cs.persistence == nil {
+		return nil
+	}
+	return errors.Wrapf(cs.persistence.UpdateConversation(cs.name, key, state), "failed to store conversation %s", cs.name)
+}
@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+	"github.com/PaulSonOfLars/gotgbot/persistence"
+)
+
+func textUpdate(chatId int, userId int, text string) *gotgbot.Update {
+	msg := &ext.Message{Text: text, Chat: &ext.Chat{Id: chatId}, From: &ext.User{Id: userId}}
+	return &gotgbot.Update{Message: msg, EffectiveMessage: msg, EffectiveChat: msg.Chat, EffectiveUser: msg.From}
+}
+
+// textHandler handles messages with exactly the given text, by returning the given result.
+func textHandler(text string, result error) handlers.Message {
+	return handlers.NewMessage(func(m *ext.Message) bool { return m.Text == text }, func(b ext.Bot, u *gotgbot.Update) error {
+		return result
+	})
+}
+
+func newTestConversation() handlers.Conversation {
+	return handlers.NewConversation("test",
+		[]gotgbot.Handler{textHandler("start", handlers.NextState("asked"))},
+		map[string][]gotgbot.Handler{
+			"asked": {textHandler("answer", handlers.NextState("confirm"))},
+			"confirm": {
+				textHandler("yes", handlers.EndConversation{}),
+				textHandler("bad", handlers.NextState("missing")),
+			},
+		},
+		[]gotgbot.Handler{textHandler("cancel", handlers.EndConversation{})},
+	)
+}
+
+// send checks and handles an update, like the dispatcher would, and returns whether the conversation handled it.
+func send(t *testing.T, c handlers.Conversation, u *gotgbot.Update) bool {
+	t.Helper()
+	ok, err := c.CheckUpdate(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		if err := c.HandleUpdate(u, gotgbot.Dispatcher{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ok
+}
+
+func expectState(t *testing.T, c handlers.Conversation, u *gotgbot.Update, want string) {
+	t.Helper()
+	state, ok := c.CurrentState(u)
+	if want == "" && ok {
+		t.Fatalf("expected no conversation, got state %q", state)
+	}
+	if want != "" && state != want {
+		t.Fatalf("expected state %q, got %q", want, state)
+	}
+}
+
+func TestConversationMovesThroughStates(t *testing.T) {
+	c := newTestConversation()
+	if send(t, c, textUpdate(1, 1, "answer")) {
+		t.Fatal("state handlers were used before the conversation started")
+	}
+	for _, step := range []struct{ text, state string }{
+		{"start", "asked"},
+		{"answer", "confirm"},
+		{"yes", ""},
+	} {
+		u := textUpdate(1, 1, step.text)
+		if !send(t, c, u) {
+			t.Fatalf("%q wasn't handled", step.text)
+		}
+		expectState(t, c, u, step.state)
+	}
+}
+
+func TestConversationFallbacks(t *testing.T) {
+	c := newTestConversation()
+	send(t, c, textUpdate(1, 1, "start"))
+	if !send(t, c, textUpdate(1, 1, "cancel")) {
+		t.Fatal("fallback wasn't used")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "")
+}
+
+func TestConversationRejectsUnknownStates(t *testing.T) {
+	c := newTestConversation()
+	send(t, c, textUpdate(1, 1, "start"))
+	send(t, c, textUpdate(1, 1, "answer"))
+	if err := c.HandleUpdate(textUpdate(1, 1, "bad"), gotgbot.Dispatcher{}); err == nil {
+		t.Fatal("expected an error for a state which doesn't exist")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "confirm")
+}
+
+func TestConversationReEntry(t *testing.T) {
+	c := newTestConversation()
+	send(t, c, textUpdate(1, 1, "start"))
+	send(t, c, textUpdate(1, 1, "answer"))
+	if send(t, c, textUpdate(1, 1, "start")) {
+		t.Fatal("conversation was restarted without AllowReEntry")
+	}
+
+	c.AllowReEntry = true
+	if !send(t, c, textUpdate(1, 1, "start")) {
+		t.Fatal("conversation wasn't restarted")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "asked")
+}
+
+func TestConversationKeyStrategies(t *testing.T) {
+	for _, tc := range []struct {
+		strategy handlers.KeyStrategy
+		// whether the conversation started by user 1 in chat 1 is shared with user 2 in chat 1, and user 1 in chat 2.
+		sameChat, sameUser bool
+	}{
+		{handlers.KeySenderAndChat, false, false},
+		{handlers.KeySender, false, true},
+		{handlers.KeyChat, true, false},
+	} {
+		c := newTestConversation()
+		c.KeyStrategy = tc.strategy
+		send(t, c, textUpdate(1, 1, "start"))
+		if _, ok := c.CurrentState(textUpdate(1, 2, "")); ok != tc.sameChat {
+			t.Errorf("strategy %d: other user in the same chat sees the conversation: %v", tc.strategy, ok)
+		}
+		if _, ok := c.CurrentState(textUpdate(2, 1, "")); ok != tc.sameUser {
+			t.Errorf("strategy %d: same user in another chat sees the conversation: %v", tc.strategy, ok)
+		}
+	}
+}
+
+func TestConversationTimeout(t *testing.T) {
+	c := newTestConversation()
+	c.Timeouts["asked"] = 20 * time.Millisecond
+	send(t, c, textUpdate(1, 1, "start"))
+	expectState(t, c, textUpdate(1, 1, ""), "asked")
+
+	time.Sleep(30 * time.Millisecond)
+	// the conversation has ended, so its state handlers are no longer used.
+	if send(t, c, textUpdate(1, 1, "answer")) {
+		t.Fatal("timed out conversation was continued")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "")
+}
+
+func TestZeroValueConversation(t *testing.T) {
+	c := handlers.Conversation{
+		EntryPoints: []gotgbot.Handler{textHandler("start", handlers.NextState("asked"))},
+		States:      map[string][]gotgbot.Handler{"asked": {textHandler("answer", handlers.EndConversation{})}},
+	}
+	c.Name = "zero value"
+	if !send(t, c, textUpdate(1, 1, "start")) {
+		t.Fatal("entry point wasn't used")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "asked")
+	if !send(t, c, textUpdate(1, 1, "answer")) {
+		t.Fatal("state handler wasn't used")
+	}
+	expectState(t, c, textUpdate(1, 1, ""), "")
+}
+
+func TestConversationPersistence(t *testing.T) {
+	p := persistence.NewMemory()
+	c := newTestConversation()
+	if err := c.SetPersistence(p); err != nil {
+		t.Fatal(err)
+	}
+	send(t, c, textUpdate(1, 1, "start"))
+
+	restarted := newTestConversation()
+	if err := restarted.SetPersistence(p); err != nil {
+		t.Fatal(err)
+	}
+	expectState(t, restarted, textUpdate(1, 1, ""), "asked")
+	send(t, restarted, textUpdate(1, 1, "answer"))
+	send(t, restarted, textUpdate(1, 1, "yes"))
+	if states, _ := p.GetConversations("test"); len(states) != 0 {
+		t.Fatalf("ended conversation is still stored: %v", states)
+	}
+}
+
+func TestConversationEndToEnd(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Dispatcher.Ordering = gotgbot.PerChat
+	u.Dispatcher.AddHandler(handlers.NewConversation("greet",
+		[]gotgbot.Handler{handlers.NewCommand("start", func(b ext.Bot, u *gotgbot.Update) error {
+			if _, err := u.EffectiveMessage.ReplyText("name?"); err != nil {
+				return err
+			}
+			return handlers.NextState("name")
+		})},
+		map[string][]gotgbot.Handler{"name": {handlers.NewMessage(Filters.Text, func(b ext.Bot, u *gotgbot.Update) error {
+			if _, err := u.EffectiveMessage.ReplyText("hi " + u.EffectiveMessage.Text); err != nil {
+				return err
+			}
+			return handlers.EndConversation{}
+		})}},
+		nil,
+	))
+	u.StartPolling()
+	defer u.Stop()
+
+	user := gotgbottest.User{Id: 42, FirstName: "Ann"}
+	chat := srv.PrivateChat(user)
+	for _, text := range []string{"bob", "/start", "bob", "again"} {
+		srv.SendMessage(chat, user, text)
+	}
+	waitForReplies(t, srv, 2)
+	time.Sleep(50 * time.Millisecond) // "again" isn't answered; give it time to be handled anyway.
+
+	var replies []string
+	for _, m := range srv.Messages(chat.Id) {
+		if m.From.Id == srv.BotUser.Id {
+			replies = append(replies, m.ReplyToMessage.Text+" -> "+m.Text)
+		}
+	}
+	if len(replies) != 2 || replies[0] != "/start -> name?" || replies[1] != "bob -> hi bob" {
+		t.Fatalf("unexpected replies %q", replies)
+	}
+}
+
+// waitForReplies waits until the bot has sent n messages.
+func waitForReplies(t *testing.T, srv *gotgbottest.Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srv.CallsTo("sendMessage")) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("bot sent %d messages, expected %d", len(srv.CallsTo("sendMessage")), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,82 @@
+package Filters
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+// Filter is a message filter which can be combined with others. Any of the filters in this package can be used as a
+// Filter, either directly as an argument, or by conversion: Filter(Text).And(Private).
+type Filter func(message *ext.Message) bool
+
+// And matches messages which match both filters.
+func (f Filter) And(other Filter) Filter {
+	return And(f, other)
+}
+
+// Or matches messages which match either filter.
+func (f Filter) Or(other Filter) Filter {
+	return Or(f, other)
+}
+
+// Xor matches messages which match exactly one of the two filters.
+func (f Filter) Xor(other Filter) Filter {
+	return Xor(f, other)
+}
+
+// Not matches messages which don't match the filter.
+func (f Filter) Not() Filter {
+	return Not(f)
+}
+
+// And matches messages which match all of the filters.
+func And(filters ...Filter) Filter {
+	return AllOf(filters)
+}
+
+// Or matches messages which match at least one of the filters.
+func Or(filters ...Filter) Filter {
+	return AnyOf(filters)
+}
+
+func Not(f Filter) Filter {
+	return func(m *ext.Message) bool {
+		return !f(m)
+	}
+}
+
+func Xor(a Filter, b Filter) Filter {
+	return func(m *ext.Message) bool {
+		return a(m) != b(m)
+	}
+}
+
+// Any matches messages which match at least one of the filters; it is the same as Or.
+func Any(filters ...Filter) Filter {
+	return AnyOf(filters)
+}
+
+// AllOf matches messages which match all of the filters; it is the same as And, for lists of filters built at
+// runtime. Unlike Any, it isn't called All, because All is already the filter which matches every message.
+func AllOf(filters []Filter) Filter {
+	return func(m *ext.Message) bool {
+		for _, f := range filters {
+			if !f(m) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf matches messages which match at least one of the filters; it is the same as Or, for lists of filters built
+// at runtime.
+func AnyOf(filters []Filter) Filter {
+	return func(m *ext.Message) bool {
+		for _, f := range filters {
+			if f(m) {
+				return true
+			}
+		}
+		return false
+	}
+}
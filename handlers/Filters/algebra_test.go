@@ -0,0 +1,37 @@
+package Filters_test
+
+import (
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+func TestCombinators(t *testing.T) {
+	text := &ext.Message{Text: "hi", Chat: &ext.Chat{Type: "private"}}
+	doc := &ext.Message{Document: &ext.Document{}, Chat: &ext.Chat{Type: "group"}}
+	both := &ext.Message{Text: "hi", Document: &ext.Document{}, Chat: &ext.Chat{Type: "private"}}
+
+	for _, c := range []struct {
+		name   string
+		filter Filters.Filter
+		want   [3]bool // text, doc, both
+	}{
+		{"And", Filters.And(Filters.Text, Filters.Document), [3]bool{false, false, true}},
+		{"Or", Filters.Or(Filters.Text, Filters.Document), [3]bool{true, true, true}},
+		{"Xor", Filters.Xor(Filters.Text, Filters.Document), [3]bool{true, true, false}},
+		{"Not", Filters.Not(Filters.Text), [3]bool{false, true, false}},
+		{"Any", Filters.Any(Filters.Text, Filters.Group), [3]bool{true, true, true}},
+		{"AllOf", Filters.AllOf([]Filters.Filter{Filters.Text, Filters.Private}), [3]bool{true, false, true}},
+		{"AnyOf", Filters.AnyOf([]Filters.Filter{Filters.Document, Filters.Group}), [3]bool{false, true, true}},
+		{"fluent", Filters.Filter(Filters.Text).And(Filters.Private).And(Filters.Filter(Filters.Document).Not()), [3]bool{true, false, false}},
+		{"fluent or", Filters.Filter(Filters.Group).Or(Filters.Document).Xor(Filters.Text), [3]bool{true, true, false}},
+		{"no filters", Filters.AllOf(nil).And(Filters.Filter(Filters.AnyOf(nil)).Not()), [3]bool{true, true, true}},
+	} {
+		for i, m := range []*ext.Message{text, doc, both} {
+			if got := c.filter(m); got != c.want[i] {
+				t.Errorf("%s: message %d: expected %v", c.name, i, c.want[i])
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package Filters
 
 import (
+	"path"
+	"regexp"
 	"strings"
 
 	"github.com/PaulSonOfLars/gotgbot/ext"
@@ -15,7 +17,7 @@ func Text(message *ext.Message) bool {
 }
 
 func Caption(message *ext.Message) bool {
-    return message.Caption != ""
+	return message.Caption != ""
 }
 
 func Command(message *ext.Message) bool {
@@ -79,20 +81,38 @@ func Game(message *ext.Message) bool {
 }
 
 func Private(message *ext.Message) bool {
-	return message.Chat.Type == "private"
+	return message.Chat != nil && message.Chat.Type == "private"
 }
 
 func Group(message *ext.Message) bool {
-	return message.Chat.Type == "group" || message.Chat.Type == "supergroup"
+	return message.Chat != nil && (message.Chat.Type == "group" || message.Chat.Type == "supergroup")
 }
 
-func Username(name string) func(message *ext.Message) bool {
+func Dice(message *ext.Message) bool {
+	return message.Dice != nil
+}
+
+func Poll(message *ext.Message) bool {
+	return message.Poll != nil
+}
+
+// ViaBot matches messages sent through an inline bot.
+func ViaBot(message *ext.Message) bool {
+	return message.ViaBot != nil
+}
+
+// SenderChat matches messages sent on behalf of a chat, such as channel posts.
+func SenderChat(message *ext.Message) bool {
+	return message.SenderChat != nil
+}
+
+func Username(name string) Filter {
 	return func(m *ext.Message) bool {
-		return m.From.Username == name
+		return m.From != nil && m.From.Username == name
 	}
 }
 
-func Entity(entType string) func(message *ext.Message) bool {
+func Entity(entType string) Filter {
 	return func(m *ext.Message) bool {
 		for _, ent := range m.Entities {
 			if ent.Type == entType {
@@ -103,7 +123,7 @@ func Entity(entType string) func(message *ext.Message) bool {
 	}
 }
 
-func CaptionEntity(entType string) func(message *ext.Message) bool {
+func CaptionEntity(entType string) Filter {
 	return func(m *ext.Message) bool {
 		for _, ent := range m.CaptionEntities {
 			if ent.Type == entType {
@@ -114,56 +134,137 @@ func CaptionEntity(entType string) func(message *ext.Message) bool {
 	}
 }
 
-func UserID(id int) func(message *ext.Message) bool {
+func UserID(id int) Filter {
 	return func(m *ext.Message) bool {
-		return m.From.Id == id
+		return m.From != nil && m.From.Id == id
 	}
 }
 
-func Chatusername(name string) func(message *ext.Message) bool {
+func Chatusername(name string) Filter {
 	return func(m *ext.Message) bool {
 		return m.Chat != nil && m.Chat.Username != "" && m.Chat.Username == name
 	}
 }
 
-func ChatID(id int) func(message *ext.Message) bool {
+func ChatID(id int) Filter {
 	return func(m *ext.Message) bool {
 		return m.Chat != nil && m.Chat.Id == id
 	}
 }
 
-func NewChatMembers() func(message *ext.Message) bool {
+func NewChatMembers() Filter {
 	return func(m *ext.Message) bool {
 		return m.NewChatMembers != nil
 	}
 }
 
-func LeftChatMembers() func(message *ext.Message) bool {
+func LeftChatMembers() Filter {
 	return func(m *ext.Message) bool {
 		return m.LeftChatMember != nil
 	}
 }
 
-func Migrate() func(message *ext.Message) bool {
+func Migrate() Filter {
 	return func(m *ext.Message) bool {
 		return m.MigrateFromChatId != 0 || m.MigrateToChatId != 0
 	}
 }
 
-func MigrateFrom() func(message *ext.Message) bool {
+func MigrateFrom() Filter {
 	return func(m *ext.Message) bool {
 		return m.MigrateFromChatId != 0
 	}
 }
 
-func MigrateTo() func(message *ext.Message) bool {
+func MigrateTo() Filter {
 	return func(m *ext.Message) bool {
 		return m.MigrateToChatId != 0
 	}
 }
 
-func StartsWith(prefix string) func(message *ext.Message) bool {
+func StartsWith(prefix string) Filter {
 	return func(m *ext.Message) bool {
 		return (m.Text != "" && strings.HasPrefix(m.Text, prefix)) || (m.Caption != "" && strings.HasPrefix(m.Caption, prefix))
 	}
 }
+
+// Regex matches messages whose text or caption matches the regex.
+func Regex(re *regexp.Regexp) Filter {
+	return func(m *ext.Message) bool {
+		return (m.Text != "" && re.MatchString(m.Text)) || (m.Caption != "" && re.MatchString(m.Caption))
+	}
+}
+
+// Language matches messages from users whose language is the given IETF language tag, such as "en", which also
+// matches regional variants such as "en-GB".
+func Language(code string) Filter {
+	return func(m *ext.Message) bool {
+		return m.From != nil && (m.From.LanguageCode == code || strings.HasPrefix(m.From.LanguageCode, code+"-"))
+	}
+}
+
+// ChatType matches messages sent in any of the given chat types: "private", "group", "supergroup" or "channel".
+func ChatType(types ...string) Filter {
+	return func(m *ext.Message) bool {
+		if m.Chat == nil {
+			return false
+		}
+		for _, t := range types {
+			if m.Chat.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MimeType matches messages with a file of the given mime type. A type ending in "/*", such as "image/*", matches
+// all of its subtypes.
+func MimeType(mimeType string) Filter {
+	return func(m *ext.Message) bool {
+		mt := fileMimeType(m)
+		if mt == "" {
+			return false
+		}
+		if strings.HasSuffix(mimeType, "/*") {
+			return strings.HasPrefix(mt, strings.TrimSuffix(mimeType, "*"))
+		}
+		return mt == mimeType
+	}
+}
+
+// FileExtension matches messages with a file whose name has the given extension, eg "pdf" or ".pdf".
+// Extensions are compared case-insensitively.
+func FileExtension(extension string) Filter {
+	extension = "." + strings.TrimPrefix(extension, ".")
+	return func(m *ext.Message) bool {
+		name := fileName(m)
+		return name != "" && strings.EqualFold(path.Ext(name), extension)
+	}
+}
+
+func fileMimeType(m *ext.Message) string {
+	switch {
+	case m.Document != nil:
+		return m.Document.MimeType
+	case m.Audio != nil:
+		return m.Audio.MimeType
+	case m.Video != nil:
+		return m.Video.MimeType
+	case m.Voice != nil:
+		return m.Voice.MimeType
+	case m.Animation != nil:
+		return m.Animation.MimeType
+	}
+	return ""
+}
+
+func fileName(m *ext.Message) string {
+	switch {
+	case m.Document != nil:
+		return m.Document.FileName
+	case m.Animation != nil:
+		return m.Animation.FileName
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package Filters_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+func TestFiltersAcceptChannelPosts(t *testing.T) {
+	// channel posts have no sender.
+	post := &ext.Message{Text: "hi", Chat: &ext.Chat{Type: "channel"}, SenderChat: &ext.Chat{Type: "channel"}}
+	for name, f := range map[string]Filters.Filter{
+		"Username": Filters.Username("ann"),
+		"UserID":   Filters.UserID(42),
+		"Language": Filters.Language("en"),
+		"Private":  Filters.Private,
+		"Group":    Filters.Group,
+	} {
+		if f(post) {
+			t.Errorf("%s matched a channel post", name)
+		}
+	}
+	if !Filters.SenderChat(post) || !Filters.ChatType("group", "channel")(post) {
+		t.Error("channel post wasn't matched")
+	}
+	if Filters.Private(&ext.Message{}) || Filters.ChatType("private")(&ext.Message{}) || Filters.ChatID(0)(&ext.Message{}) {
+		t.Error("a message without a chat was matched")
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	doc := &ext.Message{Caption: "report 2020", Document: &ext.Document{FileName: "Report.PDF", MimeType: "application/pdf"}}
+	for _, c := range []struct {
+		name   string
+		filter Filters.Filter
+		msg    *ext.Message
+		want   bool
+	}{
+		{"regex on caption", Filters.Regex(regexp.MustCompile(`\d{4}`)), doc, true},
+		{"regex on text", Filters.Regex(regexp.MustCompile(`^hi`)), &ext.Message{Text: "hi there"}, true},
+		{"regex no match", Filters.Regex(regexp.MustCompile(`^hi`)), doc, false},
+		{"mime type", Filters.MimeType("application/pdf"), doc, true},
+		{"mime wildcard", Filters.MimeType("application/*"), doc, true},
+		{"other mime", Filters.MimeType("image/*"), doc, false},
+		{"no file", Filters.MimeType("application/*"), &ext.Message{Text: "hi"}, false},
+		{"extension", Filters.FileExtension("pdf"), doc, true},
+		{"dotted extension", Filters.FileExtension(".pdf"), doc, true},
+		{"other extension", Filters.FileExtension("doc"), doc, false},
+		{"language", Filters.Language("en"), &ext.Message{From: &ext.User{LanguageCode: "en"}}, true},
+		{"regional language", Filters.Language("en"), &ext.Message{From: &ext.User{LanguageCode: "en-GB"}}, true},
+		{"language prefix", Filters.Language("e"), &ext.Message{From: &ext.User{LanguageCode: "en"}}, false},
+		{"via bot", Filters.ViaBot, &ext.Message{ViaBot: &ext.User{}}, true},
+		{"dice", Filters.Dice, &ext.Message{Dice: &ext.Dice{}}, true},
+		{"poll", Filters.Poll, &ext.Message{}, false},
+	} {
+		if got := c.filter(c.msg); got != c.want {
+			t.Errorf("%s: expected %v", c.name, c.want)
+		}
+	}
+}
+
+func TestCombinedFiltersInHandlers(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := make(chan string, 3)
+	h := handlers.NewMessage(Filters.Filter(Filters.Text).And(Filters.Username("ann").Or(Filters.SenderChat)), func(b ext.Bot, u *gotgbot.Update) error {
+		texts <- u.EffectiveMessage.Text
+		return nil
+	})
+	h.AllowChannel = true
+	u.Dispatcher.AddHandler(h)
+	u.StartPolling()
+	defer u.Stop()
+
+	ann := gotgbottest.User{Id: 1, FirstName: "Ann", Username: "ann"}
+	bob := gotgbottest.User{Id: 2, FirstName: "Bob", Username: "bob"}
+	srv.SendMessage(srv.PrivateChat(bob), bob, "from bob")
+	srv.PushUpdate(map[string]interface{}{"channel_post": map[string]interface{}{
+		"message_id":  1,
+		"date":        time.Now().Unix(),
+		"chat":        map[string]interface{}{"id": -100, "type": "channel"},
+		"sender_chat": map[string]interface{}{"id": -100, "type": "channel"},
+		"text":        "from the channel",
+	}})
+	srv.SendMessage(srv.PrivateChat(ann), ann, "from ann")
+
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case text := <-texts:
+			got[text] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only received %v", got)
+		}
+	}
+	if !got["from ann"] || !got["from the channel"] {
+		t.Fatalf("unexpected messages %v", got)
+	}
+	select {
+	case text := <-texts:
+		t.Fatalf("unexpected message %q", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
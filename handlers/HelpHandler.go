@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+)
+
+// Help replies with a list of the dispatcher's commands, leaving out those which aren't listed for the current chat
+// or user. Commands scoped to administrators are only shown to administrators.
+type Help struct {
+	baseCommand
+	// Header is sent above the list of commands.
+	Header string
+}
+
+func NewHelp() Help {
+	return Help{
+		baseCommand: baseCommand{
+			baseHandler: baseHandler{
+				Name: "help",
+			},
+			Triggers:     []rune("/"),
+			AllowEdited:  false,
+			AllowChannel: false,
+			Command:      "help",
+			Description:  "Show the available commands",
+		},
+		Header: "Available commands:",
+	}
+}
+
+func (h Help) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	text := strings.Builder{}
+	text.WriteString(h.Header)
+	check := scopeChecker{bot: d.Bot, u: u}
+	for _, c := range d.Commands() {
+		ok, err := check.listed(c)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check scopes of command %s", c.Command)
+		}
+		if !ok {
+			continue
+		}
+		text.WriteString("\n/" + c.Command)
+		if c.Description != "" {
+			text.WriteString(" - " + c.Description)
+		}
+	}
+	_, err := u.EffectiveMessage.ReplyText(strings.TrimSpace(text.String()))
+	return err
+}
+
+// scopeChecker checks which command scopes an update's chat and user are in. Whether the user is an administrator
+// is only looked up when needed, at most once.
+type scopeChecker struct {
+	bot     ext.Bot
+	u       *gotgbot.Update
+	admin   bool
+	checked bool
+}
+
+func (sc *scopeChecker) listed(c gotgbot.CommandInfo) (bool, error) {
+	if len(c.Scopes) == 0 {
+		return true, nil
+	}
+	for _, s := range c.Scopes {
+		ok, err := sc.inScope(s)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (sc *scopeChecker) inScope(s ext.BotCommandScope) (bool, error) {
+	chat, user := sc.u.EffectiveChat, sc.u.EffectiveUser
+	if chat == nil {
+		return s.Type == ext.BotCommandScopeDefault, nil
+	}
+	group := chat.Type == "group" || chat.Type == "supergroup"
+	switch s.Type {
+	case ext.BotCommandScopeDefault:
+		return true, nil
+	case ext.BotCommandScopeAllPrivateChats:
+		return chat.Type == "private", nil
+	case ext.BotCommandScopeAllGroupChats:
+		return group, nil
+	case ext.BotCommandScopeAllChatAdministrators:
+		if !group {
+			return false, nil
+		}
+		return sc.isAdmin()
+	case ext.BotCommandScopeChat:
+		return chat.Id == s.ChatId, nil
+	case ext.BotCommandScopeChatAdministrators:
+		if chat.Id != s.ChatId {
+			return false, nil
+		}
+		return sc.isAdmin()
+	case ext.BotCommandScopeChatMember:
+		return chat.Id == s.ChatId && user != nil && user.Id == s.UserId, nil
+	}
+	return false, nil
+}
+
+func (sc *scopeChecker) isAdmin() (bool, error) {
+	if sc.checked {
+		return sc.admin, nil
+	}
+	if sc.u.EffectiveUser == nil {
+		return false, nil
+	}
+	member, err := sc.bot.GetChatMember(sc.u.EffectiveChat.Id, sc.u.EffectiveUser.Id)
+	if err != nil {
+		return false, err
+	}
+	sc.checked = true
+	sc.admin = member.Status == "creator" || member.Status == "administrator"
+	return sc.admin, nil
+}
@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func scopedCommand(command string, scopes ...ext.BotCommandScope) handlers.Command {
+	cmd := handlers.NewCommand(command, func(b ext.Bot, u *gotgbot.Update) error { return nil })
+	cmd.Description = "Does " + command
+	cmd.Scopes = scopes
+	return cmd
+}
+
+func TestHelpListsCommandsForTheChat(t *testing.T) {
+	hidden := scopedCommand("secret")
+	hidden.Hidden = true
+	survey := handlers.NewConversation("survey", []gotgbot.Handler{scopedCommand("survey")},
+		map[string][]gotgbot.Handler{"asked": {scopedCommand("answer")}}, nil)
+	srv := startUpdater(t,
+		scopedCommand("start"),
+		scopedCommand("settings", ext.BotCommandScope{Type: ext.BotCommandScopeAllPrivateChats}),
+		scopedCommand("ban", ext.BotCommandScope{Type: ext.BotCommandScopeAllChatAdministrators}),
+		scopedCommand("here", ext.BotCommandScope{Type: ext.BotCommandScopeChat, ChatId: -5}),
+		hidden,
+		survey,
+		handlers.NewHelp(),
+	)
+	status := "member"
+	srv.Handle("getChatMember", func(c gotgbottest.Call) (interface{}, error) {
+		return map[string]interface{}{"status": status, "user": testUser}, nil
+	})
+	group := srv.AddChat(gotgbottest.Chat{Id: -5, Type: "supergroup", Title: "Group"})
+	other := srv.AddChat(gotgbottest.Chat{Id: -6, Type: "supergroup", Title: "Other"})
+
+	for _, c := range []struct {
+		name   string
+		chat   gotgbottest.Chat
+		status string
+		want   string
+	}{
+		{"private", srv.PrivateChat(testUser), "member", "/start - Does start\n/settings - Does settings\n/survey - Does survey"},
+		{"group member", group, "member", "/start - Does start\n/here - Does here\n/survey - Does survey"},
+		{"group admin", group, "administrator", "/start - Does start\n/ban - Does ban\n/here - Does here\n/survey - Does survey"},
+		{"other group", other, "creator", "/start - Does start\n/ban - Does ban\n/survey - Does survey"},
+	} {
+		status = c.status
+		srv.ResetCalls()
+		srv.SendMessage(c.chat, testUser, "/help")
+		call, ok := srv.WaitForCall("sendMessage", 2*time.Second)
+		if !ok {
+			t.Fatalf("%s: help wasn't sent", c.name)
+		}
+		want := "Available commands:\n" + c.want + "\n/help - Show the available commands"
+		if text := call.Params.Get("text"); text != want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", c.name, want, text)
+		}
+	}
+}
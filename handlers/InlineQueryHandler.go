@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+type InlineQuery struct {
+	baseHandler
+	// Pattern is matched against the query text; if nil, all inline queries match. The capture groups are stored in
+	// the update's Match.
+	Pattern  *regexp.Regexp
+	Response func(b ext.Bot, u *gotgbot.Update) error
+}
+
+// NewInlineQuery creates a handler for inline queries whose text matches the pattern; an empty pattern matches all
+// inline queries.
+func NewInlineQuery(pattern string, response func(b ext.Bot, u *gotgbot.Update) error) (InlineQuery, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return InlineQuery{}, err
+	}
+	return InlineQuery{
+		baseHandler: baseHandler{
+			Name: pattern,
+		},
+		Pattern:  re,
+		Response: response,
+	}, nil
+}
+
+func (h InlineQuery) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	if h.Pattern != nil {
+		u.Match = gotgbot.NewRegexMatch(h.Pattern, u.InlineQuery.Query)
+	}
+	return h.Response(d.Bot, u)
+}
+
+func (h InlineQuery) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	if u.InlineQuery == nil {
+		return false, nil
+	}
+	return h.Pattern == nil || h.Pattern.MatchString(u.InlineQuery.Query), nil
+}
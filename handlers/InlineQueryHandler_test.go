@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+var testUser = gotgbottest.User{Id: 42, FirstName: "Ann"}
+
+// startUpdater starts polling a new test server with the given handlers.
+func startUpdater(t *testing.T, hs ...gotgbot.Handler) *gotgbottest.Server {
+	t.Helper()
+	srv := gotgbottest.NewServer()
+	t.Cleanup(srv.Close)
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hs {
+		u.Dispatcher.AddHandler(h)
+	}
+	u.StartPolling()
+	t.Cleanup(func() { u.Stop() })
+	return srv
+}
+
+func TestInlineQueryPattern(t *testing.T) {
+	h, err := handlers.NewInlineQuery(`^find (?P<what>\w+)$`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]bool{"find cats": true, "find": false, "find two things": false} {
+		q := &ext.InlineQuery{Query: query}
+		if ok, _ := h.CheckUpdate(&gotgbot.Update{InlineQuery: q}); ok != want {
+			t.Errorf("%q: expected %v", query, want)
+		}
+	}
+	if ok, _ := h.CheckUpdate(textUpdate(1, 1, "find cats")); ok {
+		t.Error("messages aren't inline queries")
+	}
+	if _, err := handlers.NewInlineQuery("(", nil); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestInlineQueryPagesResults(t *testing.T) {
+	h, err := handlers.NewInlineQuery(`^find (?P<what>\w+)$`, func(b ext.Bot, u *gotgbot.Update) error {
+		if u.EffectiveMessage != nil || u.EffectiveUser == nil || u.EffectiveUser.Id != testUser.Id {
+			t.Errorf("inline query isn't a message, and comes from the user: %+v, %+v", u.EffectiveMessage, u.EffectiveUser)
+		}
+		var results []ext.InlineResult
+		for i := 0; i < 7; i++ {
+			results = append(results, ext.InlineQueryResultArticle{
+				Type:                "article",
+				Id:                  strconv.Itoa(i),
+				Title:               u.Match.Named["what"] + " " + u.Match.Group(1),
+				InputMessageContent: ext.InputTextMessageContent{MessageText: "found"},
+			})
+		}
+		_, err := u.InlineQuery.AnswerPage(results, 5)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, h)
+
+	for _, page := range []struct {
+		offset, next, first string
+		results             int
+	}{
+		{"", "5", "0", 5},
+		{"5", "", "5", 2},
+	} {
+		srv.ResetCalls()
+		q := srv.SendInlineQuery(testUser, "find cats", page.offset)
+		c, ok := srv.WaitForCall("answerInlineQuery", 2*time.Second)
+		if !ok {
+			t.Fatal("inline query wasn't answered")
+		}
+		if c.Params.Get("inline_query_id") != q.Id || c.Params.Get("next_offset") != page.next {
+			t.Fatalf("offset %q: unexpected params %v", page.offset, c.Params)
+		}
+		var results []struct {
+			Id                  string `json:"id"`
+			Title               string `json:"title"`
+			InputMessageContent struct {
+				MessageText string `json:"message_text"`
+			} `json:"input_message_content"`
+		}
+		if err := json.Unmarshal([]byte(c.Params.Get("results")), &results); err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != page.results || results[0].Id != page.first {
+			t.Fatalf("offset %q: unexpected results %+v", page.offset, results)
+		}
+		if results[0].Title != "cats cats" || results[0].InputMessageContent.MessageText != "found" {
+			t.Fatalf("offset %q: unexpected results %+v", page.offset, results)
+		}
+	}
+}
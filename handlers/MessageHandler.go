@@ -3,9 +3,11 @@ package handlers
 import (
 	"github.com/PaulSonOfLars/gotgbot"
 	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
 )
 
-type FilterFunc func(message *ext.Message) bool
+// FilterFunc is the same type as Filters.Filter, so that combined filters can be used directly.
+type FilterFunc = Filters.Filter
 
 type Message struct {
 	baseHandler
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+type PreCheckoutQuery struct {
+	baseHandler
+	// PayloadPrefix only accepts queries for invoices whose payload starts with it.
+	PayloadPrefix string
+	// Filter, if set, must also accept the query.
+	Filter   func(q *ext.PreCheckoutQuery) bool
+	Response func(b ext.Bot, u *gotgbot.Update) error
+}
+
+func NewPreCheckoutQuery(payloadPrefix string, response func(b ext.Bot, u *gotgbot.Update) error) PreCheckoutQuery {
+	return PreCheckoutQuery{
+		baseHandler: baseHandler{
+			Name: payloadPrefix,
+		},
+		PayloadPrefix: payloadPrefix,
+		Response:      response,
+	}
+}
+
+func (h PreCheckoutQuery) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	return h.Response(d.Bot, u)
+}
+
+func (h PreCheckoutQuery) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	if u.PreCheckoutQuery == nil || !strings.HasPrefix(u.PreCheckoutQuery.InvoicePayload, h.PayloadPrefix) {
+		return false, nil
+	}
+	return h.Filter == nil || h.Filter(u.PreCheckoutQuery), nil
+}
@@ -0,0 +1,46 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestPreCheckoutQueryAnswers(t *testing.T) {
+	h := handlers.NewPreCheckoutQuery("shop:", func(b ext.Bot, u *gotgbot.Update) error {
+		if u.PreCheckoutQuery.TotalAmount > 1000 {
+			_, err := u.PreCheckoutQuery.AnswerError("too expensive")
+			return err
+		}
+		_, err := u.PreCheckoutQuery.Answer()
+		return err
+	})
+	h.Filter = func(q *ext.PreCheckoutQuery) bool { return q.Currency == "EUR" }
+	srv := startUpdater(t, h)
+
+	srv.SendPreCheckoutQuery(testUser, "other:1", "EUR", 500)
+	srv.SendPreCheckoutQuery(testUser, "shop:1", "USD", 500)
+	q := srv.SendPreCheckoutQuery(testUser, "shop:1", "EUR", 500)
+	c, ok := srv.WaitForCall("answerPreCheckoutQuery", 2*time.Second)
+	if !ok {
+		t.Fatal("pre-checkout query wasn't answered")
+	}
+	if c.Params.Get("pre_checkout_query_id") != q.Id || c.Params.Get("ok") != "true" {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+
+	srv.ResetCalls()
+	q = srv.SendPreCheckoutQuery(testUser, "shop:2", "EUR", 5000)
+	if c, ok = srv.WaitForCall("answerPreCheckoutQuery", 2*time.Second); !ok {
+		t.Fatal("pre-checkout query wasn't answered")
+	}
+	if c.Params.Get("pre_checkout_query_id") != q.Id || c.Params.Get("ok") != "false" || c.Params.Get("error_message") != "too expensive" {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+	if n := len(srv.CallsTo("answerPreCheckoutQuery")); n != 1 {
+		t.Fatalf("filtered queries were answered: %d answers", n)
+	}
+}
@@ -5,40 +5,60 @@ import (
 
 	"github.com/PaulSonOfLars/gotgbot"
 	"github.com/PaulSonOfLars/gotgbot/ext"
-	"github.com/pkg/errors"
 )
 
 type Regex struct {
 	baseHandler
-	Match    string
-	Response func(b ext.Bot, u *gotgbot.Update) error
+	// Match is matched against the message text; if nil, all messages match. The capture groups are stored in the
+	// update's Match.
+	Match *regexp.Regexp
+	// AllowEdited and AllowChannel match edited messages and channel posts; NewRegex allows both, as regex handlers
+	// always have.
+	AllowEdited  bool
+	AllowChannel bool
+	// AllowCaption matches the regex against the caption of messages without text.
+	AllowCaption bool
+	Response     func(b ext.Bot, u *gotgbot.Update) error
 }
 
-func NewRegex(match string, response func(b ext.Bot, u *gotgbot.Update) error) Regex {
+// NewRegex creates a handler for messages whose text matches the pattern; an empty pattern matches all messages.
+func NewRegex(match string, response func(b ext.Bot, u *gotgbot.Update) error) (Regex, error) {
+	re, err := compilePattern(match)
+	if err != nil {
+		return Regex{}, err
+	}
 	return Regex{
 		baseHandler: baseHandler{
 			Name: match,
 		},
-		Match:    match,
-		Response: response,
-	}
+		Match:        re,
+		AllowEdited:  true,
+		AllowChannel: true,
+		AllowCaption: true,
+		Response:     response,
+	}, nil
 }
 
 func (h Regex) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	if h.Match != nil {
+		u.Match = gotgbot.NewRegexMatch(h.Match, h.text(u.EffectiveMessage))
+	}
 	return h.Response(d.Bot, u)
 }
 
 func (h Regex) CheckUpdate(u *gotgbot.Update) (bool, error) {
-	if u.EffectiveMessage == nil {
+	if !(u.Message != nil ||
+		(h.AllowEdited && u.EditedMessage != nil) ||
+		(h.AllowChannel && u.ChannelPost != nil) ||
+		(h.AllowEdited && h.AllowChannel && u.EditedChannelPost != nil)) {
 		return false, nil
 	}
-	text := u.EffectiveMessage.Text
-	if text == "" {
-		text = u.EffectiveMessage.Caption
-	}
-	res, err := regexp.Match(h.Match, []byte(text))
-	if err != nil {
-		return false, errors.Wrapf(err, "Could not match regexp")
+	return h.Match == nil || h.Match.MatchString(h.text(u.EffectiveMessage)), nil
+}
+
+func (h Regex) text(m *ext.Message) string {
+	if m.Text == "" && h.AllowCaption {
+		return m.Caption
 	}
-	return res, nil
+	return m.Text
 }
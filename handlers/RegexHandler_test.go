@@ -0,0 +1,124 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestRegexRejectsInvalidPatterns(t *testing.T) {
+	if _, err := handlers.NewRegex("(", nil); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestRegexUpdateTypes(t *testing.T) {
+	h, err := handlers.NewRegex("^hi", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &ext.Message{Text: "hi", Chat: &ext.Chat{Id: 1}}
+	updates := map[string]*gotgbot.Update{
+		"message":             {Message: msg, EffectiveMessage: msg},
+		"edited message":      {EditedMessage: msg, EffectiveMessage: msg},
+		"channel post":        {ChannelPost: msg, EffectiveMessage: msg},
+		"edited channel post": {EditedChannelPost: msg, EffectiveMessage: msg},
+	}
+	for name, u := range updates {
+		if ok, _ := h.CheckUpdate(u); !ok {
+			t.Errorf("%s isn't matched by default", name)
+		}
+	}
+	h.AllowEdited, h.AllowChannel = false, false
+	for name, u := range updates {
+		if ok, _ := h.CheckUpdate(u); ok != (name == "message") {
+			t.Errorf("%s: expected %v", name, name == "message")
+		}
+	}
+	cb := &gotgbot.Update{CallbackQuery: &ext.CallbackQuery{Message: msg}, EffectiveMessage: msg}
+	if ok, _ := h.CheckUpdate(cb); ok {
+		t.Error("callback queries aren't messages")
+	}
+}
+
+func TestRegexCaptions(t *testing.T) {
+	h, err := handlers.NewRegex("^hi", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &ext.Message{Caption: "hi"}
+	u := &gotgbot.Update{Message: msg, EffectiveMessage: msg}
+	if ok, _ := h.CheckUpdate(u); !ok {
+		t.Error("caption wasn't matched")
+	}
+	h.AllowCaption = false
+	if ok, _ := h.CheckUpdate(u); ok {
+		t.Error("caption was matched")
+	}
+}
+
+func TestZeroValueRegexMatchesEverything(t *testing.T) {
+	handled := false
+	h := handlers.Regex{Response: func(b ext.Bot, u *gotgbot.Update) error {
+		handled = true
+		return nil
+	}}
+	u := textUpdate(1, 1, "anything")
+	if ok, err := h.CheckUpdate(u); !ok || err != nil {
+		t.Fatalf("expected a match, got %v, %v", ok, err)
+	}
+	if err := h.HandleUpdate(u, gotgbot.Dispatcher{}); err != nil || !handled {
+		t.Fatalf("update wasn't handled: %v", err)
+	}
+	if u.Match != nil {
+		t.Fatalf("unexpected match %+v", u.Match)
+	}
+}
+
+func TestEmptyRegexPatternMatchesEverything(t *testing.T) {
+	h, err := handlers.NewRegex("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Match != nil {
+		t.Fatalf("expected no regex, got %v", h.Match)
+	}
+	u := textUpdate(1, 1, "anything")
+	if ok, err := h.CheckUpdate(u); !ok || err != nil {
+		t.Fatalf("expected a match, got %v, %v", ok, err)
+	}
+	if u.Match != nil {
+		t.Fatalf("unexpected match %+v", u.Match)
+	}
+}
+
+func TestRegexCaptureGroups(t *testing.T) {
+	got := make(chan string, 2)
+	h, err := handlers.NewRegex(`^buy (?P<count>\d+)`, func(b ext.Bot, u *gotgbot.Update) error {
+		got <- u.Match.Named["count"] + "/" + u.Match.Group(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startUpdater(t, h)
+
+	chat := srv.PrivateChat(testUser)
+	m := srv.SendMessage(chat, testUser, "buy 12")
+	for _, want := range []string{"12/12", "13/13"} {
+		select {
+		case s := <-got:
+			if s != want {
+				t.Fatalf("expected %q, got %q", want, s)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%q wasn't handled", want)
+		}
+		if want == "12/12" {
+			srv.EditMessage(m, "buy 13")
+		}
+	}
+}
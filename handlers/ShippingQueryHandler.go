@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+type ShippingQuery struct {
+	baseHandler
+	// PayloadPrefix only accepts queries for invoices whose payload starts with it.
+	PayloadPrefix string
+	// Filter, if set, must also accept the query.
+	Filter   func(q *ext.ShippingQuery) bool
+	Response func(b ext.Bot, u *gotgbot.Update) error
+}
+
+func NewShippingQuery(payloadPrefix string, response func(b ext.Bot, u *gotgbot.Update) error) ShippingQuery {
+	return ShippingQuery{
+		baseHandler: baseHandler{
+			Name: payloadPrefix,
+		},
+		PayloadPrefix: payloadPrefix,
+		Response:      response,
+	}
+}
+
+func (h ShippingQuery) HandleUpdate(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+	return h.Response(d.Bot, u)
+}
+
+func (h ShippingQuery) CheckUpdate(u *gotgbot.Update) (bool, error) {
+	if u.ShippingQuery == nil || !strings.HasPrefix(u.ShippingQuery.InvoicePayload, h.PayloadPrefix) {
+		return false, nil
+	}
+	return h.Filter == nil || h.Filter(u.ShippingQuery), nil
+}
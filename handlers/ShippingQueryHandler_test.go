@@ -0,0 +1,66 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+func TestShippingQueryFilters(t *testing.T) {
+	h := handlers.NewShippingQuery("shop:", nil)
+	h.Filter = func(q *ext.ShippingQuery) bool { return q.ShippingAddress.CountryCode != "XX" }
+	for _, c := range []struct {
+		payload, country string
+		want             bool
+	}{
+		{"shop:1", "DE", true},
+		{"other:1", "DE", false},
+		{"shop:1", "XX", false},
+	} {
+		q := &ext.ShippingQuery{InvoicePayload: c.payload, ShippingAddress: ext.ShippingAddress{CountryCode: c.country}}
+		if ok, _ := h.CheckUpdate(&gotgbot.Update{ShippingQuery: q}); ok != c.want {
+			t.Errorf("%q from %q: expected %v", c.payload, c.country, c.want)
+		}
+	}
+	if ok, _ := h.CheckUpdate(textUpdate(1, 1, "shop:1")); ok {
+		t.Error("messages aren't shipping queries")
+	}
+}
+
+func TestShippingQueryAnswers(t *testing.T) {
+	srv := startUpdater(t, handlers.NewShippingQuery("shop:", func(b ext.Bot, u *gotgbot.Update) error {
+		if u.ShippingQuery.ShippingAddress.CountryCode != "DE" {
+			_, err := u.ShippingQuery.AnswerError("we only ship to Germany")
+			return err
+		}
+		_, err := u.ShippingQuery.Answer(ext.ShippingOption{Id: "post", Title: "Post", Prices: []ext.LabeledPrice{{Label: "Post", Amount: 500}}})
+		return err
+	}))
+
+	q := srv.SendShippingQuery(testUser, "shop:1", gotgbottest.ShippingAddress{CountryCode: "FR"})
+	c, ok := srv.WaitForCall("answerShippingQuery", 2*time.Second)
+	if !ok {
+		t.Fatal("shipping query wasn't answered")
+	}
+	if c.Params.Get("shipping_query_id") != q.Id || c.Params.Get("ok") != "false" || c.Params.Get("error_message") != "we only ship to Germany" {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+
+	srv.ResetCalls()
+	q = srv.SendShippingQuery(testUser, "shop:1", gotgbottest.ShippingAddress{CountryCode: "DE"})
+	if c, ok = srv.WaitForCall("answerShippingQuery", 2*time.Second); !ok {
+		t.Fatal("shipping query wasn't answered")
+	}
+	var options []ext.ShippingOption
+	if err := json.Unmarshal([]byte(c.Params.Get("shipping_options")), &options); err != nil {
+		t.Fatal(err)
+	}
+	if c.Params.Get("shipping_query_id") != q.Id || c.Params.Get("ok") != "true" || len(options) != 1 || options[0].Prices[0].Amount != 500 {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+}
@@ -1,5 +1,12 @@
 package handlers
 
+import (
+	"regexp"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/pkg/errors"
+)
+
 type baseHandler struct {
 	Name string
 }
@@ -7,3 +14,28 @@ type baseHandler struct {
 func (h baseHandler) GetName() string {
 	return h.Name
 }
+
+// compilePattern compiles a handler's pattern; an empty pattern compiles to nil, which handlers treat as matching
+// everything.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not compile regexp %q", pattern)
+	}
+	return re, nil
+}
+
+// autoAnswer answers the update's callback query if enabled and it is still unanswered, after a response has returned
+// err. A failure to answer is only returned if the response itself succeeded.
+func autoAnswer(enabled bool, u *gotgbot.Update, err error) error {
+	if !enabled || u.CallbackQuery == nil {
+		return err
+	}
+	if answerErr := u.CallbackQuery.EnsureAnswered(); answerErr != nil && err == nil {
+		return errors.Wrap(answerErr, "failed to answer callback query")
+	}
+	return err
+}
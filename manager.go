@@ -0,0 +1,225 @@
+package gotgbot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/pkg/errors"
+)
+
+// BotManagerOpts configures how a BotManager runs its bots.
+type BotManagerOpts struct {
+	// Requester is used by all bots, so that they share a single http client and its connection pool.
+	// If nil, ext.DefaultTgBotGetter is used.
+	Requester ext.TgBotGetterInterface
+	// Setup is called for each bot as it is added, before it starts receiving updates; eg to add the same
+	// handlers to each bot's dispatcher. Handlers which keep state, such as conversations, should be created anew
+	// for each bot.
+	Setup func(u *Updater) error
+	// Webhook, if set, makes all bots receive updates through the manager's ServeHTTP rather than by polling.
+	// Each bot is served at ServePath/<bot id>, and given its own secret token.
+	Webhook *Webhook
+	// Polling is used by bots when Webhook is nil. If nil, DefaultPollingOptions is used.
+	Polling *PollingOptions
+}
+
+// BotManager runs many bots in a single process. Bots can be added and removed while others keep running.
+type BotManager struct {
+	opts   BotManagerOpts
+	mu     sync.RWMutex
+	bots   map[string]*managedBot  // by token
+	adding map[string]bool         // tokens of the bots being added
+	routes map[string]http.Handler // webhook handlers, by path
+}
+
+type managedBot struct {
+	updater *Updater
+	path    string       // the path of the bot's webhook, if it uses one
+	handler http.Handler // the bot's webhook handler, if it uses one
+}
+
+func NewBotManager(opts BotManagerOpts) *BotManager {
+	return &BotManager{
+		opts:   opts,
+		bots:   map[string]*managedBot{},
+		adding: map[string]bool{},
+		routes: map[string]http.Handler{},
+	}
+}
+
+// AddBot creates an updater for the token, sets it up, and starts receiving its updates. Other bots can be added
+// and removed while it is being set up.
+func (m *BotManager) AddBot(token string) (*Updater, error) {
+	m.mu.Lock()
+	if _, ok := m.bots[token]; ok || m.adding[token] {
+		m.mu.Unlock()
+		return nil, errors.New("bot has already been added")
+	}
+	m.adding[token] = true
+	m.mu.Unlock()
+
+	b, err := m.newBot(token)
+
+	m.mu.Lock()
+	added := m.adding[token] // false if the manager was shut down in the meantime
+	delete(m.adding, token)
+	if err == nil && added {
+		m.bots[token] = b
+		if b.path != "" {
+			m.routes["/"+b.path] = b.handler
+		}
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		b.updater.Stop()
+		return nil, errors.New("bot manager was shut down while the bot was being added")
+	}
+	return b.updater, nil
+}
+
+// newBot creates and sets up the bot's updater, and starts it receiving updates, either by setting its webhook or by
+// polling. The bot is fully started before it is added, so that stopping it never races with starting it.
+func (m *BotManager) newBot(token string) (*managedBot, error) {
+	u, err := NewUpdaterWithOpts(token, UpdaterOpts{Requester: m.opts.Requester})
+	if err != nil {
+		return nil, err
+	}
+	if m.opts.Setup != nil {
+		if err := m.opts.Setup(u); err != nil {
+			return nil, errors.Wrapf(err, "failed to set up bot %s", u.Bot.UserName)
+		}
+	}
+
+	b := &managedBot{updater: u}
+	if m.opts.Webhook != nil {
+		b.path, err = m.setWebhook(u)
+		if err != nil {
+			return nil, err
+		}
+		b.handler = u.WebhookHandler()
+		return b, nil
+	}
+
+	polling := DefaultPollingOptions
+	if m.opts.Polling != nil {
+		polling = *m.opts.Polling
+	}
+	if err := u.StartPollingWithOptions(polling); err != nil {
+		return nil, errors.Wrapf(err, "failed to start polling for bot %s", u.Bot.UserName)
+	}
+	return b, nil
+}
+
+// setWebhook points the bot's webhook at its own path, and returns that path.
+func (m *BotManager) setWebhook(u *Updater) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "failed to generate secret token")
+	}
+	webhook := *m.opts.Webhook
+	webhook.SecretToken = hex.EncodeToString(secret)
+
+	path := strconv.Itoa(u.Bot.Id)
+	if prefix := strings.Trim(webhook.ServePath, "/"); prefix != "" {
+		path = prefix + "/" + path
+	}
+	if _, err := u.SetWebhook(path, webhook); err != nil {
+		return "", errors.Wrapf(err, "failed to set webhook for bot %s", u.Bot.UserName)
+	}
+	return path, nil
+}
+
+// RemoveBot stops the bot, waiting for it to finish handling the updates it has already received. Other bots keep
+// running. The bot's webhook isn't removed, so telegram keeps any new updates until the bot is added again.
+func (m *BotManager) RemoveBot(token string) error {
+	m.mu.Lock()
+	b, ok := m.bots[token]
+	if ok {
+		delete(m.bots, token)
+		if b.path != "" {
+			delete(m.routes, "/"+b.path)
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return errors.New("no bot with that token")
+	}
+	return b.updater.Stop()
+}
+
+// Bot returns the updater of the bot with the given token.
+func (m *BotManager) Bot(token string) (*Updater, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.bots[token]
+	if !ok {
+		return nil, false
+	}
+	return b.updater, true
+}
+
+// Tokens returns the tokens of all running bots.
+func (m *BotManager) Tokens() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tokens := make([]string, 0, len(m.bots))
+	for token := range m.bots {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// ServeHTTP passes webhook requests on to the bot they are for, so a single server can receive the updates of all
+// bots.
+func (m *BotManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	h, ok := m.routes[r.URL.Path]
+	m.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
+
+// Shutdown stops all bots, as Updater.Shutdown does. The first error encountered is returned, but all bots are
+// stopped regardless.
+func (m *BotManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	bots := m.bots
+	m.bots = map[string]*managedBot{}
+	m.adding = map[string]bool{}
+	m.routes = map[string]http.Handler{}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(bots))
+	for _, b := range bots {
+		wg.Add(1)
+		go func(u *Updater) {
+			defer wg.Done()
+			if err := u.Shutdown(ctx); err != nil {
+				errs <- errors.Wrapf(err, "failed to stop bot %s", u.Bot.UserName)
+			}
+		}(b.updater)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// Stop stops all bots, waiting for each to finish handling the updates it has already received.
+func (m *BotManager) Stop() error {
+	return m.Shutdown(context.Background())
+}
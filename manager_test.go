@@ -0,0 +1,195 @@
+package gotgbot_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+)
+
+func TestManagerServesWebhooks(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	h, texts := textReceiver()
+	m := gotgbot.NewBotManager(gotgbot.BotManagerOpts{
+		Requester: srv.Requester(),
+		Webhook:   &gotgbot.Webhook{URL: "https://example.com", ServePath: "/bots/"},
+		Setup: func(u *gotgbot.Updater) error {
+			u.Dispatcher.AddHandler(h)
+			return nil
+		},
+	})
+	defer m.Stop()
+	if _, err := m.AddBot("1:WRONG"); err == nil {
+		t.Fatal("expected an invalid token to be rejected")
+	}
+	if _, err := m.AddBot(gotgbottest.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddBot(gotgbottest.Token); err == nil {
+		t.Fatal("expected a bot to only be added once")
+	}
+
+	c := srv.CallsTo("setWebhook")[0]
+	path := "/bots/" + strconv.Itoa(srv.BotUser.Id)
+	if c.Params.Get("url") != "https://example.com"+path || c.Params.Get("secret_token") == "" {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+	hs := httptest.NewServer(m)
+	defer hs.Close()
+	secret := c.Params.Get("secret_token")
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL+"/bots/1", "application/json", secret, webhookUpdate); code != http.StatusNotFound {
+		t.Fatalf("unknown bot's path: expected 404, got %d", code)
+	}
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL+path, "application/json", secret, webhookUpdate); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	expectText(t, texts, "hi")
+
+	if err := m.RemoveBot(gotgbottest.Token); err != nil {
+		t.Fatal(err)
+	}
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL+path, "application/json", secret, webhookUpdate); code != http.StatusNotFound {
+		t.Fatalf("removed bot's path: expected 404, got %d", code)
+	}
+	if len(m.Tokens()) != 0 {
+		t.Fatalf("removed bot is still listed: %v", m.Tokens())
+	}
+}
+
+func TestManagerPollsBots(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	h, texts := textReceiver()
+	m := gotgbot.NewBotManager(gotgbot.BotManagerOpts{
+		Requester: srv.Requester(),
+		Polling:   &gotgbot.PollingOptions{Timeout: time.Second},
+		Setup: func(u *gotgbot.Updater) error {
+			u.Dispatcher.AddHandler(h)
+			return nil
+		},
+	})
+	u, err := m.AddBot(gotgbottest.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := m.Bot(gotgbottest.Token); !ok || got != u {
+		t.Fatal("bot isn't listed")
+	}
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	expectText(t, texts, "hi")
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Bot(gotgbottest.Token); ok {
+		t.Fatal("stopped bot is still listed")
+	}
+}
+
+func TestManagerIsUsableWhileAddingBots(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	release := make(chan struct{})
+	srv.Handle("getMe", func(c gotgbottest.Call) (interface{}, error) {
+		<-release
+		return srv.BotUser, nil
+	})
+	m := gotgbot.NewBotManager(gotgbot.BotManagerOpts{Requester: srv.Requester()})
+	defer m.Stop()
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := m.AddBot(gotgbottest.Token)
+		added <- err
+	}()
+	// wait for the bot to be waiting on getMe.
+	if _, ok := srv.WaitForCall("getMe", 2*time.Second); !ok {
+		t.Fatal("bot was never created")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Tokens()
+		m.Bot("other")
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/other", strings.NewReader("{}")))
+		if _, err := m.AddBot(gotgbottest.Token); err == nil {
+			t.Error("the bot being added was added twice")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("manager was locked while a bot was being added")
+	}
+
+	close(release)
+	if err := <-added; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerShutdownWhileAddingBots(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	release := make(chan struct{})
+	srv.Handle("getMe", func(c gotgbottest.Call) (interface{}, error) {
+		<-release
+		return srv.BotUser, nil
+	})
+	m := gotgbot.NewBotManager(gotgbot.BotManagerOpts{Requester: srv.Requester()})
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := m.AddBot(gotgbottest.Token)
+		added <- err
+	}()
+	if _, ok := srv.WaitForCall("getMe", 2*time.Second); !ok {
+		t.Fatal("bot was never created")
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-added; err == nil {
+		t.Fatal("expected adding a bot to a stopped manager to fail")
+	}
+	if len(m.Tokens()) != 0 {
+		t.Fatalf("bot was added after the manager stopped: %v", m.Tokens())
+	}
+}
+
+func TestManagerAddBotRacesWithShutdown(t *testing.T) {
+	srv := gotgbottest.NewServer()
+	defer srv.Close()
+	for i := 0; i < 20; i++ {
+		m := gotgbot.NewBotManager(gotgbot.BotManagerOpts{
+			Requester: srv.Requester(),
+			Polling:   &gotgbot.PollingOptions{Timeout: time.Second},
+		})
+		added := make(chan struct{})
+		go func() {
+			defer close(added)
+			m.AddBot(gotgbottest.Token)
+		}()
+		// shut down as soon as the bot is listed, while AddBot may still be returning.
+		for deadline := time.Now().Add(2 * time.Second); ; runtime.Gosched() {
+			if _, ok := m.Bot(gotgbottest.Token); ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("bot was never added")
+			}
+		}
+		if err := m.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		<-added
+	}
+}
@@ -0,0 +1,36 @@
+package gotgbot
+
+// UpdateFunc processes an update; it is the type wrapped by middleware.
+type UpdateFunc func(u *Update, d Dispatcher) error
+
+// Middleware wraps the processing of an update, and can run code before and after it. It can stop the update from
+// being processed any further by returning without calling next.
+//
+// Errors returned by middleware are treated as if a handler had returned them: returning EndGroups from a group's
+// middleware stops any later groups from being checked.
+type Middleware func(next UpdateFunc) UpdateFunc
+
+type middlewares struct {
+	global []Middleware
+	groups map[int][]Middleware
+}
+
+// Use adds middleware which runs around the processing of every update, before any handler groups are checked.
+// Middleware is run in the order it was added.
+func (d Dispatcher) Use(mw ...Middleware) {
+	d.middleware.global = append(d.middleware.global, mw...)
+}
+
+// UseGroup adds middleware which runs around the processing of a single handler group. It is only run for updates
+// which reach that group.
+func (d Dispatcher) UseGroup(group int, mw ...Middleware) {
+	d.middleware.groups[group] = append(d.middleware.groups[group], mw...)
+}
+
+// chain wraps f in the given middleware, such that the first middleware is the outermost one.
+func chain(mw []Middleware, f UpdateFunc) UpdateFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		f = mw[i](f)
+	}
+	return f
+}
@@ -0,0 +1,142 @@
+package gotgbot_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+// recorder records what happens while updates are processed.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) expect(t *testing.T, events ...string) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !reflect.DeepEqual(r.events, events) {
+		t.Fatalf("expected %q, got %q", events, r.events)
+	}
+	r.events = nil
+}
+
+// recordingHandler records its name for every text message it handles, and returns err.
+func recordingHandler(r *recorder, name string, err error) handlers.Message {
+	return handlers.NewMessage(Filters.Text, func(b ext.Bot, u *gotgbot.Update) error {
+		r.add(name)
+		return err
+	})
+}
+
+// recordingMiddleware records its name before and after the rest of the processing.
+func recordingMiddleware(r *recorder, name string) gotgbot.Middleware {
+	return func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			r.add(name + " before")
+			err := next(u, d)
+			r.add(name + " after")
+			return err
+		}
+	}
+}
+
+// waitForProcessing adds middleware which signals each time an update has been fully processed; it has to be added
+// before any other middleware.
+func waitForProcessing(d *gotgbot.Dispatcher) <-chan struct{} {
+	processed := make(chan struct{}, 10)
+	d.Use(func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			defer func() { processed <- struct{}{} }()
+			return next(u, d)
+		}
+	})
+	return processed
+}
+
+func waitFor(t *testing.T, processed <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-processed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("update was never processed")
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	processed := waitForProcessing(u.Dispatcher)
+	r := &recorder{}
+	u.Dispatcher.Use(recordingMiddleware(r, "first"), recordingMiddleware(r, "second"))
+	u.Dispatcher.UseGroup(1, recordingMiddleware(r, "group 1"))
+	u.Dispatcher.UseGroup(2, recordingMiddleware(r, "group 2"))
+	u.Dispatcher.AddHandler(recordingHandler(r, "handler 0", nil))
+	u.Dispatcher.AddHandlerToGroup(recordingHandler(r, "handler 1", gotgbot.EndGroups{}), 1)
+	u.Dispatcher.AddHandlerToGroup(recordingHandler(r, "handler 2", nil), 2)
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	waitFor(t, processed)
+	// group 2 is never reached, so neither is its middleware.
+	r.expect(t, "first before", "second before", "handler 0", "group 1 before", "handler 1", "group 1 after",
+		"second after", "first after")
+}
+
+func TestMiddlewareCanStopUpdates(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	processed := waitForProcessing(u.Dispatcher)
+	r := &recorder{}
+	banned := testUser
+	banned.Id = 666
+	u.Dispatcher.Use(func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			if u.EffectiveUser.Id == banned.Id {
+				return nil
+			}
+			return next(u, d)
+		}
+	})
+	u.Dispatcher.AddHandler(recordingHandler(r, "handler", nil))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(banned), banned, "hi")
+	waitFor(t, processed)
+	r.expect(t)
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	waitFor(t, processed)
+	r.expect(t, "handler")
+}
+
+func TestGroupMiddlewareCanEndGroups(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	processed := waitForProcessing(u.Dispatcher)
+	r := &recorder{}
+	u.Dispatcher.UseGroup(0, func(next gotgbot.UpdateFunc) gotgbot.UpdateFunc {
+		return func(u *gotgbot.Update, d gotgbot.Dispatcher) error {
+			return gotgbot.EndGroups{}
+		}
+	})
+	u.Dispatcher.AddHandler(recordingHandler(r, "handler 0", nil))
+	u.Dispatcher.AddHandlerToGroup(recordingHandler(r, "handler 1", nil), 1)
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "hi")
+	waitFor(t, processed)
+	r.expect(t)
+}
@@ -0,0 +1,93 @@
+package gotgbot
+
+import (
+	"sync"
+)
+
+// Ordering determines which updates the dispatcher may handle at the same time.
+type Ordering int
+
+const (
+	// Concurrent handles every update in its own goroutine as soon as it arrives, so updates from the same chat
+	// may be handled out of order.
+	Concurrent Ordering = iota
+	// PerChat handles the updates of each chat one at a time, in the order they arrived. Updates without a chat
+	// are ordered by their user instead; since private chats share their user's id, these are ordered together with
+	// the user's private chat.
+	PerChat
+	// PerUser handles the updates of each user one at a time, in the order they arrived.
+	PerUser
+)
+
+// key returns the key updates are ordered by; false means the update can be handled concurrently.
+func (o Ordering) key(u *Update) (int, bool) {
+	switch o {
+	case PerChat:
+		if u.EffectiveChat != nil {
+			return u.EffectiveChat.Id, true
+		}
+		fallthrough
+	case PerUser:
+		if u.EffectiveUser != nil {
+			return u.EffectiveUser.Id, true
+		}
+	}
+	return 0, false
+}
+
+// keyedQueues runs a worker for each key which has updates queued, so that updates with the same key are handled
+// in order.
+type keyedQueues struct {
+	mu     sync.Mutex
+	queues map[int]*keyedQueue
+	size   int
+	handle func(u *Update)
+}
+
+type keyedQueue struct {
+	updates chan *Update
+	pending int // updates pushed but not yet handled; guarded by the keyedQueues' lock
+}
+
+func newKeyedQueues(size int, handle func(u *Update)) *keyedQueues {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	return &keyedQueues{
+		queues: map[int]*keyedQueue{},
+		size:   size,
+		handle: handle,
+	}
+}
+
+// push queues the update, starting a worker for its key if there isn't one already. It blocks while the key's
+// queue is full.
+func (kq *keyedQueues) push(key int, u *Update) {
+	kq.mu.Lock()
+	q, ok := kq.queues[key]
+	if !ok {
+		q = &keyedQueue{updates: make(chan *Update, kq.size)}
+		kq.queues[key] = q
+		go kq.work(key, q)
+	}
+	// counting the update as pending keeps the worker from exiting before it has been handled, so the update can be
+	// queued without holding the lock.
+	q.pending++
+	kq.mu.Unlock()
+	q.updates <- u
+}
+
+func (kq *keyedQueues) work(key int, q *keyedQueue) {
+	for u := range q.updates {
+		kq.handle(u)
+
+		kq.mu.Lock()
+		q.pending--
+		if q.pending == 0 {
+			delete(kq.queues, key)
+			kq.mu.Unlock()
+			return
+		}
+		kq.mu.Unlock()
+	}
+}
@@ -0,0 +1,75 @@
+package gotgbot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+func TestOrderingKeys(t *testing.T) {
+	private := &Update{EffectiveUser: &ext.User{Id: 1}, EffectiveChat: &ext.Chat{Id: 1}}
+	group := &Update{EffectiveUser: &ext.User{Id: 1}, EffectiveChat: &ext.Chat{Id: -100}}
+	inline := &Update{EffectiveUser: &ext.User{Id: 1}}
+	channel := &Update{EffectiveChat: &ext.Chat{Id: -200}}
+	for _, tc := range []struct {
+		ordering Ordering
+		u        *Update
+		key      int
+		ordered  bool
+	}{
+		{Concurrent, group, 0, false},
+		{PerChat, group, -100, true},
+		{PerChat, private, 1, true},
+		{PerChat, inline, 1, true},
+		{PerUser, group, 1, true},
+		{PerUser, channel, 0, false},
+	} {
+		key, ordered := tc.ordering.key(tc.u)
+		if key != tc.key || ordered != tc.ordered {
+			t.Errorf("ordering %d: expected key %d (%v), got %d (%v)", tc.ordering, tc.key, tc.ordered, key, ordered)
+		}
+	}
+}
+
+func TestKeyedQueuesKeepOrderAndExit(t *testing.T) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := map[int][]int{}
+	kq := newKeyedQueues(1, func(u *Update) {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		seen[u.EffectiveChat.Id] = append(seen[u.EffectiveChat.Id], u.UpdateId)
+	})
+	for i := 0; i < 50; i++ {
+		for chat := 0; chat < 3; chat++ {
+			wg.Add(1)
+			kq.push(chat, &Update{UpdateId: i, EffectiveChat: &ext.Chat{Id: chat}})
+		}
+	}
+	wg.Wait()
+
+	for chat, ids := range seen {
+		for i, id := range ids {
+			if id != i {
+				t.Fatalf("chat %d: updates handled out of order: %v", chat, ids)
+			}
+		}
+	}
+	// workers exit once their queue is empty.
+	deadline := time.Now().Add(time.Second)
+	for {
+		kq.mu.Lock()
+		n := len(kq.queues)
+		kq.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d workers are still running", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
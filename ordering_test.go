@@ -0,0 +1,111 @@
+package gotgbot_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+func TestPerChatOrdering(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	u.Dispatcher.Ordering = gotgbot.PerChat
+	u.Dispatcher.QueueSize = 2
+	u.Dispatcher.MaxRoutines = 3
+
+	var mu sync.Mutex
+	seen := map[int][]string{}
+	running, maxRunning := 0, 0
+	u.Dispatcher.AddHandler(handlers.NewMessage(Filters.Text, func(b ext.Bot, upd *gotgbot.Update) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		seen[upd.EffectiveChat.Id] = append(seen[upd.EffectiveChat.Id], upd.EffectiveMessage.Text)
+		mu.Unlock()
+		return nil
+	}))
+	for i := 0; i < 5; i++ {
+		user := gotgbottest.User{Id: 100 + i, FirstName: "User"}
+		for j := 0; j < 20; j++ {
+			srv.SendMessage(srv.PrivateChat(user), user, strconv.Itoa(j))
+		}
+	}
+	u.StartPolling()
+	deadline := time.Now().Add(5 * time.Second)
+	for handled := 0; handled < 100; {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d updates were handled", handled)
+		}
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		handled = 0
+		for _, texts := range seen {
+			handled += len(texts)
+		}
+		mu.Unlock()
+	}
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning > 3 {
+		t.Fatalf("%d updates were handled at once, expected at most 3", maxRunning)
+	}
+	for id, texts := range seen {
+		if len(texts) != 20 {
+			t.Fatalf("chat %d: expected 20 updates, got %d", id, len(texts))
+		}
+		for j, text := range texts {
+			if text != strconv.Itoa(j) {
+				t.Fatalf("chat %d: updates handled out of order: %v", id, texts)
+			}
+		}
+	}
+}
+
+func TestPerUserOrderingInGroups(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	u.Dispatcher.Ordering = gotgbot.PerUser
+
+	// the first user's update blocks; the second user in the same group is still handled.
+	release := make(chan struct{})
+	handled := make(chan int, 2)
+	u.Dispatcher.AddHandler(handlers.NewMessage(Filters.Text, func(b ext.Bot, upd *gotgbot.Update) error {
+		if upd.EffectiveMessage.Text == "block" {
+			<-release
+		}
+		handled <- upd.EffectiveUser.Id
+		return nil
+	}))
+	u.StartPolling()
+	defer u.Stop()
+
+	group := srv.AddChat(gotgbottest.Chat{Id: -100, Type: "group", Title: "Group"})
+	other := gotgbottest.User{Id: 43, FirstName: "Bob"}
+	srv.SendMessage(group, testUser, "block")
+	srv.SendMessage(group, other, "hi")
+	select {
+	case id := <-handled:
+		if id != other.Id {
+			t.Fatalf("expected user %d's update first, got %d", other.Id, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("second user's update was held up by the first")
+	}
+	close(release)
+	<-handled
+}
@@ -0,0 +1,302 @@
+package gotgbot
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Persistence stores user, chat and bot data, as well as the state of conversations, so that they survive restarts.
+// Implementations can be found in the persistence package.
+type Persistence interface {
+	GetUserData() (map[int]map[string]interface{}, error)
+	GetChatData() (map[int]map[string]interface{}, error)
+	GetBotData() (map[string]interface{}, error)
+	// GetConversations returns the state of each conversation of the named conversation handler.
+	GetConversations(name string) (map[string]string, error)
+
+	UpdateUserData(userId int, data map[string]interface{}) error
+	UpdateChatData(chatId int, data map[string]interface{}) error
+	UpdateBotData(data map[string]interface{}) error
+	// UpdateConversation sets the state of a single conversation; an empty state means the conversation has ended.
+	UpdateConversation(name string, key string, state string) error
+
+	// Flush writes any changes to permanent storage.
+	Flush() error
+}
+
+// PersistentHandler is implemented by handlers which keep their own state, such as conversations, so that the
+// dispatcher can hand them its Persistence.
+type PersistentHandler interface {
+	Handler
+	SetPersistence(p Persistence) error
+}
+
+// Storage is a concurrency-safe key-value store for a single user, a single chat, or the whole bot.
+type Storage struct {
+	mu    sync.RWMutex
+	data  map[string]interface{}
+	dirty bool
+	refs  int // the number of updates using the storage; guarded by the dataStore's lock
+}
+
+func newStorage(data map[string]interface{}) *Storage {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return &Storage{data: data}
+}
+
+func (s *Storage) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// GetString returns the value stored at key if it is a string.
+func (s *Storage) GetString(key string) (string, bool) {
+	v, _ := s.Get(key)
+	str, ok := v.(string)
+	return str, ok
+}
+
+// GetInt returns the value stored at key if it is a number. Values loaded from JSON are decoded as floats, so
+// those are accepted as well.
+func (s *Storage) GetInt(key string) (int, bool) {
+	v, _ := s.Get(key)
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
+
+func (s *Storage) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	s.dirty = true
+}
+
+func (s *Storage) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	s.dirty = true
+}
+
+// snapshot returns a copy of the data if it has changed since the last snapshot.
+func (s *Storage) snapshot() (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil, false
+	}
+	s.dirty = false
+	data := make(map[string]interface{}, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data, true
+}
+
+// markDirty marks the data as changed, eg when a snapshot of it couldn't be stored.
+func (s *Storage) markDirty() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = true
+}
+
+// unused returns whether the storage holds nothing which still has to be kept; with store set, changes which
+// haven't been stored yet have to be kept as well.
+func (s *Storage) unused(store bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.refs == 0 && len(s.data) == 0 && !(store && s.dirty)
+}
+
+// dataStore holds the data of all users and chats the dispatcher has seen. Users and chats without any data are
+// dropped once no update is using them, so that only those with data are kept.
+type dataStore struct {
+	mu          sync.Mutex
+	persistence Persistence
+	users       map[int]*Storage
+	chats       map[int]*Storage
+	bot         *Storage
+}
+
+func newDataStore() *dataStore {
+	return &dataStore{
+		users: map[int]*Storage{},
+		chats: map[int]*Storage{},
+		bot:   newStorage(nil),
+	}
+}
+
+// load replaces all data with the data from p, and sets p as the store's persistence.
+func (ds *dataStore) load(p Persistence) error {
+	users, err := p.GetUserData()
+	if err != nil {
+		return errors.Wrap(err, "failed to load user data")
+	}
+	chats, err := p.GetChatData()
+	if err != nil {
+		return errors.Wrap(err, "failed to load chat data")
+	}
+	bot, err := p.GetBotData()
+	if err != nil {
+		return errors.Wrap(err, "failed to load bot data")
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.persistence = p
+	ds.users = map[int]*Storage{}
+	for id, data := range users {
+		ds.users[id] = newStorage(data)
+	}
+	ds.chats = map[int]*Storage{}
+	for id, data := range chats {
+		ds.chats[id] = newStorage(data)
+	}
+	ds.bot = newStorage(bot)
+	return nil
+}
+
+func (ds *dataStore) getPersistence() Persistence {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.persistence
+}
+
+// attach gives the update access to the data of its user and chat.
+func (ds *dataStore) attach(u *Update) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if u.EffectiveUser != nil {
+		u.UserData = getOrCreate(ds.users, u.EffectiveUser.Id)
+	}
+	if u.EffectiveChat != nil {
+		u.ChatData = getOrCreate(ds.chats, u.EffectiveChat.Id)
+	}
+	u.BotData = ds.bot
+}
+
+// release marks the update as done with its user and chat data, dropping it if it's empty.
+func (ds *dataStore) release(u *Update) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if u.EffectiveUser != nil {
+		release(ds.users, u.EffectiveUser.Id, u.UserData, ds.persistence != nil)
+	}
+	if u.EffectiveChat != nil {
+		release(ds.chats, u.EffectiveChat.Id, u.ChatData, ds.persistence != nil)
+	}
+}
+
+func getOrCreate(m map[int]*Storage, id int) *Storage {
+	s, ok := m[id]
+	if !ok {
+		s = newStorage(nil)
+		m[id] = s
+	}
+	s.refs++
+	return s
+}
+
+func release(m map[int]*Storage, id int, s *Storage, store bool) {
+	if s == nil || m[id] != s {
+		return
+	}
+	s.refs--
+	if s.unused(store) {
+		delete(m, id)
+	}
+}
+
+// evict drops the storages in m which are unused once their changes have been stored.
+func evict(m map[int]*Storage, flushed map[int]*Storage) {
+	for id, s := range flushed {
+		if m[id] == s && s.unused(true) {
+			delete(m, id)
+		}
+	}
+}
+
+// flushErrors holds the errors of everything which couldn't be stored during a flush.
+type flushErrors []error
+
+func (fe flushErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, err := range fe {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// flush writes all changed data to the persistence, then flushes it. Data which fails to be written is kept as
+// changed, to be retried on the next flush, and the rest is still written.
+func (ds *dataStore) flush() error {
+	ds.mu.Lock()
+	p := ds.persistence
+	users := make(map[int]*Storage, len(ds.users))
+	for id, s := range ds.users {
+		users[id] = s
+	}
+	chats := make(map[int]*Storage, len(ds.chats))
+	for id, s := range ds.chats {
+		chats[id] = s
+	}
+	bot := ds.bot
+	ds.mu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+	var errs flushErrors
+	for id, s := range users {
+		if data, changed := s.snapshot(); changed {
+			if err := p.UpdateUserData(id, data); err != nil {
+				s.markDirty()
+				errs = append(errs, errors.Wrapf(err, "failed to update data for user %d", id))
+			}
+		}
+	}
+	for id, s := range chats {
+		if data, changed := s.snapshot(); changed {
+			if err := p.UpdateChatData(id, data); err != nil {
+				s.markDirty()
+				errs = append(errs, errors.Wrapf(err, "failed to update data for chat %d", id))
+			}
+		}
+	}
+	if data, changed := bot.snapshot(); changed {
+		if err := p.UpdateBotData(data); err != nil {
+			bot.markDirty()
+			errs = append(errs, errors.Wrap(err, "failed to update bot data"))
+		}
+	}
+
+	ds.mu.Lock()
+	evict(ds.users, users)
+	evict(ds.chats, chats)
+	ds.mu.Unlock()
+
+	if err := p.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
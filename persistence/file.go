@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the encoding used to store data in a file.
+type Format int
+
+const (
+	// JSON files are human readable, but all numbers are read back as float64.
+	JSON Format = iota
+	// Gob keeps go types intact; any custom types stored must be registered with gob.Register.
+	Gob
+)
+
+// File keeps data in memory, and writes it all to a single file whenever it is flushed.
+type File struct {
+	*Memory
+	Path   string
+	Format Format
+}
+
+// NewFile loads any data already stored at path.
+func NewFile(path string, format Format) (*File, error) {
+	f := &File{
+		Memory: NewMemory(),
+		Path:   path,
+		Format: format,
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer file.Close()
+
+	if err := f.decode(file); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode %s", path)
+	}
+	if f.UserData == nil {
+		f.UserData = map[int]map[string]interface{}{}
+	}
+	if f.ChatData == nil {
+		f.ChatData = map[int]map[string]interface{}{}
+	}
+	if f.BotData == nil {
+		f.BotData = map[string]interface{}{}
+	}
+	if f.Conversations == nil {
+		f.Conversations = map[string]map[string]string{}
+	}
+	return f, nil
+}
+
+// Flush writes all the data to the file. The data is written to a temporary file first, so that a crash halfway
+// through doesn't lose the previous data.
+func (f *File) Flush() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	f.mu.RLock()
+	err = f.encode(tmp)
+	f.mu.RUnlock()
+	if err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to encode data")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "unable to write temporary file")
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), f.Path), "unable to replace %s", f.Path)
+}
+
+func (f *File) encode(w io.Writer) error {
+	if f.Format == Gob {
+		return gob.NewEncoder(w).Encode(f.Memory)
+	}
+	return json.NewEncoder(w).Encode(f.Memory)
+}
+
+func (f *File) decode(r io.Reader) error {
+	if f.Format == Gob {
+		return gob.NewDecoder(r).Decode(f.Memory)
+	}
+	return json.NewDecoder(r).Decode(f.Memory)
+}
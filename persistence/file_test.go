@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, Gob} {
+		path := filepath.Join(t.TempDir(), "data")
+		f, err := NewFile(path, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.UpdateUserData(1, map[string]interface{}{"name": "Ann"})
+		f.UpdateChatData(-100, map[string]interface{}{"lang": "en"})
+		f.UpdateBotData(map[string]interface{}{"started": true})
+		f.UpdateConversation("conv", "1:1", "ASKED")
+		if err := f.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := NewFile(path, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users, _ := loaded.GetUserData()
+		chats, _ := loaded.GetChatData()
+		bot, _ := loaded.GetBotData()
+		states, _ := loaded.GetConversations("conv")
+		if users[1]["name"] != "Ann" || chats[-100]["lang"] != "en" || bot["started"] != true || states["1:1"] != "ASKED" {
+			t.Fatalf("format %d: data wasn't loaded back: %v %v %v %v", format, users, chats, bot, states)
+		}
+	}
+}
+
+func TestFileKeepsOldDataOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	f, err := NewFile(path, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.UpdateBotData(map[string]interface{}{"version": "1"})
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// values which can't be encoded make the flush fail halfway through.
+	f.UpdateBotData(map[string]interface{}{"version": "2", "bad": make(chan int)})
+	if err := f.Flush(); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	loaded, err := NewFile(path, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bot, _ := loaded.GetBotData(); bot["version"] != "1" {
+		t.Fatalf("previous data was lost: %v", bot)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("temporary files were left behind: %d files", len(files))
+	}
+}
+
+func TestFileWithCorruptData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path, []byte("{not json"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFile(path, JSON); err == nil {
+		t.Fatal("expected corrupt data to fail to load")
+	}
+}
@@ -0,0 +1,117 @@
+// Package persistence provides implementations of gotgbot.Persistence.
+package persistence
+
+import (
+	"sync"
+)
+
+// Memory keeps all data in memory. It is lost on restart, but is useful for tests, and as a base for other
+// implementations.
+type Memory struct {
+	mu            sync.RWMutex
+	UserData      map[int]map[string]interface{}
+	ChatData      map[int]map[string]interface{}
+	BotData       map[string]interface{}
+	Conversations map[string]map[string]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		UserData:      map[int]map[string]interface{}{},
+		ChatData:      map[int]map[string]interface{}{},
+		BotData:       map[string]interface{}{},
+		Conversations: map[string]map[string]string{},
+	}
+}
+
+func (m *Memory) GetUserData() (map[int]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return copyIdData(m.UserData), nil
+}
+
+func (m *Memory) GetChatData() (map[int]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return copyIdData(m.ChatData), nil
+}
+
+func (m *Memory) GetBotData() (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return copyData(m.BotData), nil
+}
+
+func (m *Memory) GetConversations(name string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	states := map[string]string{}
+	for key, state := range m.Conversations[name] {
+		states[key] = state
+	}
+	return states, nil
+}
+
+func (m *Memory) UpdateUserData(userId int, data map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(data) == 0 {
+		delete(m.UserData, userId)
+		return nil
+	}
+	m.UserData[userId] = copyData(data)
+	return nil
+}
+
+func (m *Memory) UpdateChatData(chatId int, data map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(data) == 0 {
+		delete(m.ChatData, chatId)
+		return nil
+	}
+	m.ChatData[chatId] = copyData(data)
+	return nil
+}
+
+func (m *Memory) UpdateBotData(data map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BotData = copyData(data)
+	return nil
+}
+
+func (m *Memory) UpdateConversation(name string, key string, state string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state == "" {
+		delete(m.Conversations[name], key)
+		return nil
+	}
+	if m.Conversations[name] == nil {
+		m.Conversations[name] = map[string]string{}
+	}
+	m.Conversations[name][key] = state
+	return nil
+}
+
+// Flush does nothing, since there is nowhere else to write the data to.
+func (m *Memory) Flush() error {
+	return nil
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		cp[k] = v
+	}
+	return cp
+}
+
+func copyIdData(data map[int]map[string]interface{}) map[int]map[string]interface{} {
+	cp := make(map[int]map[string]interface{}, len(data))
+	for id, d := range data {
+		cp[id] = copyData(d)
+	}
+	return cp
+}
@@ -0,0 +1,46 @@
+package persistence
+
+import (
+	"testing"
+)
+
+func TestMemoryReturnsCopies(t *testing.T) {
+	m := NewMemory()
+	data := map[string]interface{}{"key": "value"}
+	m.UpdateUserData(1, data)
+	data["key"] = "changed"
+
+	users, _ := m.GetUserData()
+	if users[1]["key"] != "value" {
+		t.Fatalf("stored data was changed through the map it was stored from: %v", users)
+	}
+	users[1]["key"] = "changed"
+	if users, _ := m.GetUserData(); users[1]["key"] != "value" {
+		t.Fatalf("stored data was changed through the map it was read into: %v", users)
+	}
+}
+
+func TestMemoryDropsEmptyData(t *testing.T) {
+	m := NewMemory()
+	m.UpdateChatData(1, map[string]interface{}{"key": "value"})
+	m.UpdateChatData(1, map[string]interface{}{})
+	if chats, _ := m.GetChatData(); len(chats) != 0 {
+		t.Fatalf("empty chat data was kept: %v", chats)
+	}
+}
+
+func TestMemoryConversations(t *testing.T) {
+	m := NewMemory()
+	m.UpdateConversation("conv", "1:1", "ASKED")
+	m.UpdateConversation("conv", "1:2", "ASKED")
+	m.UpdateConversation("conv", "1:1", "")
+	m.UpdateConversation("other", "1:1", "DONE")
+
+	states, _ := m.GetConversations("conv")
+	if len(states) != 1 || states["1:2"] != "ASKED" {
+		t.Fatalf("unexpected states %v", states)
+	}
+	if states, _ := m.GetConversations("missing"); len(states) != 0 {
+		t.Fatalf("unknown conversation has states %v", states)
+	}
+}
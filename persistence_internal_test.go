@@ -0,0 +1,87 @@
+package gotgbot
+
+import (
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/ext"
+)
+
+// memoryPersistence is a minimal Persistence, since the persistence package can't be imported here.
+type memoryPersistence struct {
+	users map[int]map[string]interface{}
+}
+
+func (p *memoryPersistence) GetUserData() (map[int]map[string]interface{}, error) { return nil, nil }
+func (p *memoryPersistence) GetChatData() (map[int]map[string]interface{}, error) { return nil, nil }
+func (p *memoryPersistence) GetBotData() (map[string]interface{}, error)          { return nil, nil }
+func (p *memoryPersistence) GetConversations(string) (map[string]string, error)   { return nil, nil }
+func (p *memoryPersistence) UpdateUserData(id int, data map[string]interface{}) error {
+	p.users[id] = data
+	return nil
+}
+func (p *memoryPersistence) UpdateChatData(int, map[string]interface{}) error { return nil }
+func (p *memoryPersistence) UpdateBotData(map[string]interface{}) error       { return nil }
+func (p *memoryPersistence) UpdateConversation(string, string, string) error  { return nil }
+func (p *memoryPersistence) Flush() error                                     { return nil }
+
+func userUpdate(id int) *Update {
+	return &Update{EffectiveUser: &ext.User{Id: id}, EffectiveChat: &ext.Chat{Id: id}}
+}
+
+func TestEmptyDataIsDropped(t *testing.T) {
+	ds := newDataStore()
+	for id := 0; id < 10; id++ {
+		u := userUpdate(id)
+		ds.attach(u)
+		if id == 0 {
+			u.UserData.Set("kept", true)
+		}
+		ds.release(u)
+	}
+	if len(ds.users) != 1 || len(ds.chats) != 0 {
+		t.Fatalf("expected only the user with data to be kept, got %d users and %d chats", len(ds.users), len(ds.chats))
+	}
+}
+
+func TestDataInUseIsKept(t *testing.T) {
+	ds := newDataStore()
+	first, second := userUpdate(1), userUpdate(1)
+	ds.attach(first)
+	ds.attach(second)
+	ds.release(first)
+	// the second update is still running, and may still store something.
+	second.UserData.Set("kept", true)
+	ds.release(second)
+	if v, _ := ds.users[1].Get("kept"); v != true {
+		t.Fatal("data set by a running update was dropped")
+	}
+}
+
+func TestClearedDataIsDroppedOnceStored(t *testing.T) {
+	p := &memoryPersistence{users: map[int]map[string]interface{}{}}
+	ds := newDataStore()
+	if err := ds.load(p); err != nil {
+		t.Fatal(err)
+	}
+	u := userUpdate(1)
+	ds.attach(u)
+	u.UserData.Set("key", 1)
+	ds.release(u)
+	if err := ds.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	u = userUpdate(1)
+	ds.attach(u)
+	u.UserData.Delete("key")
+	ds.release(u)
+	if _, ok := ds.users[1]; !ok {
+		t.Fatal("cleared data was dropped before it was stored")
+	}
+	if err := ds.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ds.users[1]; ok || len(p.users[1]) != 0 {
+		t.Fatalf("cleared data wasn't stored and dropped: %v", p.users)
+	}
+}
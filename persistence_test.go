@@ -0,0 +1,125 @@
+package gotgbot_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/persistence"
+)
+
+// flakyPersistence fails to store user data while failing is set.
+type flakyPersistence struct {
+	*persistence.Memory
+	mu      sync.Mutex
+	failing bool
+}
+
+func (p *flakyPersistence) setFailing(failing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failing = failing
+}
+
+func (p *flakyPersistence) UpdateUserData(userId int, data map[string]interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing {
+		return errors.New("storage is down")
+	}
+	return p.Memory.UpdateUserData(userId, data)
+}
+
+// countCommand counts how often each user and chat has used /count, as well as the total.
+func countCommand(done chan<- struct{}) handlers.Command {
+	return handlers.NewCommand("count", func(b ext.Bot, u *gotgbot.Update) error {
+		for _, s := range []*gotgbot.Storage{u.UserData, u.ChatData, u.BotData} {
+			n, _ := s.GetInt("count")
+			s.Set("count", n+1)
+		}
+		done <- struct{}{}
+		return nil
+	})
+}
+
+func TestDataIsPersisted(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	p := persistence.NewMemory()
+	p.UpdateUserData(testUser.Id, map[string]interface{}{"count": 41})
+	if err := u.Dispatcher.SetPersistence(p); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	u.Dispatcher.AddHandler(countCommand(done))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/count")
+	<-done
+	if err := u.Dispatcher.FlushPersistence(); err != nil {
+		t.Fatal(err)
+	}
+	users, _ := p.GetUserData()
+	chats, _ := p.GetChatData()
+	bot, _ := p.GetBotData()
+	if users[testUser.Id]["count"] != 42 || chats[testUser.Id]["count"] != 1 || bot["count"] != 1 {
+		t.Fatalf("unexpected data: %v %v %v", users, chats, bot)
+	}
+}
+
+func TestFailedFlushIsRetried(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	p := &flakyPersistence{Memory: persistence.NewMemory(), failing: true}
+	if err := u.Dispatcher.SetPersistence(p); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	u.Dispatcher.AddHandler(countCommand(done))
+	u.StartPolling()
+	defer u.Stop()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/count")
+	<-done
+	if err := u.Dispatcher.FlushPersistence(); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	// everything else is still written.
+	chats, _ := p.GetChatData()
+	bot, _ := p.GetBotData()
+	if chats[testUser.Id]["count"] != 1 || bot["count"] != 1 {
+		t.Fatalf("the rest of the data wasn't written: %v %v", chats, bot)
+	}
+
+	p.setFailing(false)
+	if err := u.Dispatcher.FlushPersistence(); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := p.GetUserData(); users[testUser.Id]["count"] != 1 {
+		t.Fatalf("user data wasn't written once storage recovered: %v", users)
+	}
+}
+
+func TestPersistenceIsFlushedOnStop(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	p := persistence.NewMemory()
+	if err := u.Dispatcher.SetPersistence(p); err != nil {
+		t.Fatal(err)
+	}
+	u.Dispatcher.FlushInterval = time.Hour
+	done := make(chan struct{})
+	u.Dispatcher.AddHandler(countCommand(done))
+	u.StartPolling()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/count")
+	<-done
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := p.GetUserData(); users[testUser.Id]["count"] != 1 {
+		t.Fatalf("data wasn't flushed on stop: %v", users)
+	}
+}
@@ -18,7 +18,11 @@ func main() {
 	// reply to /start messages
 	updater.Dispatcher.AddHandler(handlers.NewCommand("start", start))
 	// reply to messages satisfying this regex
-	updater.Dispatcher.AddHandler(handlers.NewRegex("(?i)hello", hi))
+	hello, err := handlers.NewRegex("(?i)hello", hi)
+	if err != nil {
+		log.Fatal(err)
+	}
+	updater.Dispatcher.AddHandler(hello)
 	// reply to all messages satisfying the filter
 	updater.Dispatcher.AddHandler(handlers.NewMessage(Filters.Sticker, stickerDeleter))
 
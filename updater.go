@@ -1,12 +1,19 @@
 package gotgbot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/ext"
@@ -18,11 +25,33 @@ type Updater struct {
 	Bot        *ext.Bot
 	updates    chan *RawUpdate
 	Dispatcher *Dispatcher
+
+	server         *http.Server
+	mux            *http.ServeMux // the webhook server's routes, kept separate from http.DefaultServeMux
+	sendMu         sync.RWMutex   // held for writing while closing the updates channel
+	webhookSecret  atomic.Value   // the secret token last passed to SetWebhook or SetWebhookSecret
+	pollers        sync.WaitGroup
+	dispatcherDone chan struct{}   // closed once the dispatcher has drained all updates
+	ctx            context.Context // cancelled to request that all update sources stop
+	cancel         context.CancelFunc
+	stopped        chan struct{} // closed once polling has stopped and the dispatcher has drained all updates
+	stopOnce       sync.Once
+}
+
+// UpdaterOpts configures how an Updater is created.
+type UpdaterOpts struct {
+	// Requester executes the bot's API calls; eg, to use a different http client, or a self-hosted bot API server.
+	// If nil, ext.DefaultTgBotGetter is used.
+	Requester ext.TgBotGetterInterface
 }
 
 func NewUpdater(token string) (*Updater, error) {
+	return NewUpdaterWithOpts(token, UpdaterOpts{})
+}
+
+func NewUpdaterWithOpts(token string, opts UpdaterOpts) (*Updater, error) {
 	u := &Updater{}
-	user, err := ext.Bot{Token: token, Logger: logrus.New()}.GetMe()
+	user, err := ext.Bot{Token: token, Logger: logrus.New(), Requester: opts.Requester}.GetMe()
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to create new updater")
 	}
@@ -32,8 +61,12 @@ func NewUpdater(token string) (*Updater, error) {
 		FirstName: user.FirstName,
 		UserName:  user.Username,
 		Logger:    logrus.New(),
+		Requester: opts.Requester,
 	}
 	u.updates = make(chan *RawUpdate)
+	u.mux = http.NewServeMux()
+	u.ctx, u.cancel = context.WithCancel(context.Background())
+	u.stopped = make(chan struct{})
 	u.Dispatcher = NewDispatcher(*u.Bot, u.updates)
 	ok, err := u.RemoveWebhook() // just in case
 	if err != nil {
@@ -45,36 +78,160 @@ func NewUpdater(token string) (*Updater, error) {
 	return u, nil
 }
 
-func (u Updater) StartPolling() error {
-	go u.Dispatcher.Start()
-	go u.startPolling(false)
-	return nil
+// PollingOptions configures how updates are fetched with getUpdates.
+type PollingOptions struct {
+	// Timeout is how long telegram holds each request open while waiting for new updates. Zero means short polling.
+	Timeout time.Duration
+	// Limit is the maximum number of updates fetched by each request, between 1 and 100. Zero uses telegram's
+	// default of 100.
+	Limit int
+	// AllowedUpdates lists the types of updates to receive, eg "message" or "callback_query". If nil, telegram
+	// keeps using the previously set list.
+	AllowedUpdates []string
+	// MinBackoff is the time waited after a request fails; it doubles after each consecutive failure, up to
+	// MaxBackoff. Each wait is randomised between half and all of the backoff, so that many bots don't retry at
+	// the same time. Zero values use those of DefaultPollingOptions.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
 }
 
-func (u Updater) StartCleanPolling() error {
-	go u.Dispatcher.Start()
-	go u.startPolling(true)
+var DefaultPollingOptions = PollingOptions{
+	Timeout:    10 * time.Second,
+	MinBackoff: time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// pollTimeoutMargin is how much longer than the long poll timeout the http client waits for a response.
+const pollTimeoutMargin = 5 * time.Second
+
+func (u *Updater) StartPolling() error {
+	return u.StartPollingWithOptions(DefaultPollingOptions)
+}
+
+func (u *Updater) StartPollingWithOptions(opts PollingOptions) error {
+	u.startDispatcher()
+	u.pollers.Add(1)
+	go u.startPolling(opts)
 	return nil
 }
 
-func (u Updater) startPolling(clean bool) {
+// StartCleanPolling drops all pending updates, then starts polling for new ones.
+func (u *Updater) StartCleanPolling() error {
+	dropped, err := u.DropPendingUpdates()
+	if err != nil {
+		return err
+	}
+	logrus.Infof("dropped %d pending updates", dropped)
+	return u.StartPolling()
+}
+
+// DropPendingUpdates tells telegram to discard all updates which haven't been received yet, and returns how many
+// there were. Updates arriving in the meantime can make the count slightly inaccurate.
+// Any webhook is removed, as that is how telegram drops updates.
+func (u *Updater) DropPendingUpdates() (int, error) {
+	info, err := u.GetWebhookInfo()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count pending updates")
+	}
+
+	v := url.Values{}
+	v.Add("drop_pending_updates", strconv.FormatBool(true))
+	r, err := ext.Get(*u.Bot, "deleteWebhook", v)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to drop pending updates")
+	}
+	if !r.Ok {
+		return 0, ext.NewTelegramError("deleteWebhook", r)
+	}
+	return info.PendingUpdateCount, nil
+}
+
+// backoff returns how long to wait after the given number of consecutive failed requests.
+func (opts PollingOptions) backoff(failures int) time.Duration {
+	min, max := opts.MinBackoff, opts.MaxBackoff
+	if min <= 0 {
+		min = DefaultPollingOptions.MinBackoff
+	}
+	if max <= 0 {
+		max = DefaultPollingOptions.MaxBackoff
+	}
+	if max < min {
+		max = min
+	}
+
+	d := min
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// startDispatcher runs the dispatcher in the background, and keeps track of when it has finished draining.
+func (u *Updater) startDispatcher() {
+	if u.dispatcherDone != nil {
+		return // already running
+	}
+	u.dispatcherDone = make(chan struct{})
+	go func() {
+		u.Dispatcher.Start()
+		close(u.dispatcherDone)
+	}()
+}
+
+func (u *Updater) startPolling(opts PollingOptions) {
+	defer u.pollers.Done()
+
 	v := url.Values{}
 	v.Add("offset", strconv.Itoa(0))
-	v.Add("timeout", strconv.Itoa(0))
+	v.Add("timeout", strconv.Itoa(int(opts.Timeout/time.Second)))
+	if opts.Limit > 0 {
+		v.Add("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.AllowedUpdates != nil {
+		allowed, err := json.Marshal(opts.AllowedUpdates)
+		if err != nil {
+			logrus.WithError(err).Error("cannot marshal allowedUpdates")
+		} else {
+			v.Add("allowed_updates", string(allowed))
+		}
+	}
 	offset := 0
+	acked := 0 // the offset after the last update passed on to the dispatcher
+	failures := 0
+	// cancelling the updater's context aborts any long poll in progress.
+	pollBot := u.Bot.WithContext(u.ctx)
+	pollBot.Requester = pollingRequester(pollBot, opts.Timeout+pollTimeoutMargin)
 	for {
-		r, err := ext.Get(*u.Bot, "getUpdates", v)
-		if err != nil {
-			logrus.WithError(err).Error("unable to getUpdates")
-			continue
+		select {
+		case <-u.ctx.Done():
+			u.acknowledgeUpdates(acked)
+			return
+		default:
+		}
 
-		} else if !r.Ok {
-			logrus.Errorf("getUpdates error: %v", r.Description)
-			logrus.Errorf("Sleeping for 1 second...")
-			time.Sleep(time.Second)
+		r, err := ext.Get(pollBot, "getUpdates", v)
+		if err == nil && !r.Ok {
+			err = ext.NewTelegramError("getUpdates", r)
+		}
+		if err != nil {
+			if u.ctx.Err() != nil {
+				continue // the request was aborted because the updater is stopping
+			}
+			failures++
+			wait := opts.backoff(failures)
+			logrus.WithError(err).Errorf("unable to getUpdates; retrying in %v", wait)
+			select {
+			case <-u.ctx.Done():
+			case <-time.After(wait):
+			}
 			continue
+		}
+		failures = 0
 
-		} else if r.Result != nil {
+		if r.Result != nil {
 			var rawUpdates []json.RawMessage
 			json.Unmarshal(r.Result, &rawUpdates)
 			if len(rawUpdates) > 0 {
@@ -82,35 +239,141 @@ func (u Updater) startPolling(clean bool) {
 				lastUpd := initUpdate(RawUpdate(rawUpdates[len(rawUpdates)-1]), *u.Bot)
 				offset = lastUpd.UpdateId + 1
 				v.Set("offset", strconv.Itoa(offset))
-				if clean {
-					continue
-				}
-			} else if len(rawUpdates) == 0 { // TODO: this is unsustainable, and may eventually break on higher loads.
-				clean = false
 			}
 
 			for _, updData := range rawUpdates {
 				temp := RawUpdate(updData) // necessary to avoid memory stuff from loops
-				u.updates <- &temp
+				if err := u.queueUpdate(u.ctx, &temp); err != nil {
+					// the updater is stopping; the rest are left unacknowledged, so telegram sends them again.
+					break
+				}
+				acked = updateId(temp) + 1
 			}
 		}
 	}
 }
 
-func (u Updater) Idle() {
-	for {
-		time.Sleep(1 * time.Second)
+// updateId returns the id of a raw update.
+func updateId(upd RawUpdate) int {
+	var u struct {
+		UpdateId int `json:"update_id"`
+	}
+	json.Unmarshal(upd, &u)
+	return u.UpdateId
+}
+
+// pollingRequester returns the bot's requester, with its client timeout raised to at least the given timeout if
+// it's a TgBotGetter; otherwise long polls would be cut short.
+func pollingRequester(bot ext.Bot, timeout time.Duration) ext.TgBotGetterInterface {
+	r := bot.Requester
+	if r == nil {
+		r = &ext.DefaultTgBotGetter
+	}
+	if tbg, ok := r.(*ext.TgBotGetter); ok {
+		return tbg.WithMinTimeout(timeout)
+	}
+	return r
+}
+
+// acknowledgeUpdates tells telegram that all updates before the given offset have been received, so they aren't sent
+// again the next time the bot starts.
+func (u *Updater) acknowledgeUpdates(offset int) {
+	if offset == 0 {
+		return
+	}
+	v := url.Values{}
+	v.Add("offset", strconv.Itoa(offset))
+	v.Add("limit", strconv.Itoa(1))
+	v.Add("timeout", strconv.Itoa(0))
+	if _, err := ext.Get(*u.Bot, "getUpdates", v); err != nil {
+		logrus.WithError(err).Error("unable to acknowledge updates on shutdown")
+	}
+}
+
+// Idle blocks until the process receives a SIGINT or SIGTERM, at which point the updater is stopped; or until the
+// updater is stopped elsewhere. It only returns once all in-flight updates have been handled.
+func (u *Updater) Idle() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	select {
+	case sig := <-sigs:
+		logrus.Infof("received %v, shutting down", sig)
+		if err := u.Stop(); err != nil {
+			logrus.WithError(err).Error("failed to shut down cleanly")
+		}
+	case <-u.stopped:
 	}
 }
 
-// TODO: finish handling updates on sigint
+// Stop stops receiving new updates, and waits for the dispatcher to finish handling all the updates it has already
+// received.
+func (u *Updater) Stop() error {
+	return u.Shutdown(context.Background())
+}
+
+// Shutdown stops polling or closes the webhook server, then waits for all in-flight updates to be handled.
+// If ctx expires before the dispatcher has finished draining, the contexts of the updates still being handled are
+// cancelled, and Shutdown returns the context's error. Polling is stopped and the dispatcher is drained regardless,
+// so Shutdown can be called again to keep waiting for it.
+func (u *Updater) Shutdown(ctx context.Context) error {
+	var err error
+	u.stopOnce.Do(func() {
+		err = u.stopReceiving(ctx)
+		go func() {
+			u.pollers.Wait()
+			if u.dispatcherDone != nil {
+				<-u.dispatcherDone
+			}
+			u.Dispatcher.cancelInFlight()
+			close(u.stopped)
+		}()
+	})
+
+	select {
+	case <-u.stopped:
+		return err
+	case <-ctx.Done():
+		u.Dispatcher.cancelInFlight()
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(ctx.Err(), "timed out waiting for in-flight updates")
+	}
+}
+
+// stopReceiving stops all sources of updates, and tells the dispatcher there are no more. If the webhook server
+// can't be shut down gracefully before ctx expires, it is closed, and the error is returned.
+func (u *Updater) stopReceiving(ctx context.Context) error {
+	u.cancel()
+
+	var err error
+	if u.server != nil {
+		if shutdownErr := u.server.Shutdown(ctx); shutdownErr != nil {
+			u.server.Close()
+			err = errors.Wrap(shutdownErr, "failed to shut down webhook server")
+		}
+	}
+
+	// every sender goes through queueUpdate, which gives up once the context is cancelled; so once the write lock is
+	// held, nothing can be sending updates, and the dispatcher can be told there are no more.
+	u.sendMu.Lock()
+	close(u.updates)
+	u.sendMu.Unlock()
+	return err
+}
 
 type Webhook struct {
-	Serve     string // base url to where you listen
-	ServePath string // path you listen to
-	ServePort int    // port you listen on
-	URL       string // where you set the webhook to send to
-	//CertPath       string   // TODO
+	Serve          string   // base url to where you listen
+	ServePath      string   // path you listen to
+	ServePort      int      // port you listen on
+	URL            string   // where you set the webhook to send to
+	CertPath       string   // path to the public certificate; the webhook is served over TLS if this and KeyPath are set
+	KeyPath        string   // path to the certificate's private key
+	UploadCert     bool     // upload the certificate when setting the webhook; needed for self-signed certificates
+	SecretToken    string   // sent by telegram with every update, so that other requests can be rejected
+	DropPending    bool     // drop all pending updates when setting the webhook
 	MaxConnections int      // max connections; max 100, default 40
 	AllowedUpdates []string // which updates to allow
 }
@@ -125,34 +388,41 @@ func (w Webhook) GetListenUrl() string {
 	return fmt.Sprintf("%s:%d", w.Serve, w.ServePort)
 }
 
-func (u Updater) StartWebhook(webhook Webhook) {
-	go u.Dispatcher.Start()
-	http.HandleFunc("/"+webhook.ServePath, func(w http.ResponseWriter, r *http.Request) {
-		bytes, _ := ioutil.ReadAll(r.Body)
-		temp := RawUpdate(bytes)
-		u.updates <- &temp
-	})
+// StartWebhook starts a server listening for updates on the webhook's ServePath. Requests without the webhook's
+// SecretToken are rejected.
+func (u *Updater) StartWebhook(webhook Webhook) {
+	u.startDispatcher()
+	path := "/" + webhook.ServePath
+	u.mux.Handle(path, u.webhookHandler(path, func() string { return webhook.SecretToken }))
+	u.server = &http.Server{Addr: webhook.GetListenUrl(), Handler: u.mux}
 	go func() {
-		// todo: TLS when using certs
-		err := http.ListenAndServe(webhook.GetListenUrl(), nil)
-		if err != nil {
+		var err error
+		if webhook.CertPath != "" && webhook.KeyPath != "" {
+			err = u.server.ListenAndServeTLS(webhook.CertPath, webhook.KeyPath)
+		} else {
+			err = u.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logrus.Fatal(errors.WithStack(err))
 		}
 	}()
 }
 
-func (u Updater) RemoveWebhook() (bool, error) {
+func (u *Updater) RemoveWebhook() (bool, error) {
 	r, err := ext.Get(*u.Bot, "deleteWebhook", nil)
 	if err != nil {
 		return false, errors.Wrapf(err, "failed to remove webhook")
 	}
+	if !r.Ok {
+		return false, ext.NewTelegramError("deleteWebhook", r)
+	}
 	var bb bool
 	json.Unmarshal(r.Result, &bb)
 
 	return bb, nil
 }
 
-func (u Updater) SetWebhook(path string, webhook Webhook) (bool, error) {
+func (u *Updater) SetWebhook(path string, webhook Webhook) (bool, error) {
 	allowedUpdates := webhook.AllowedUpdates
 	if allowedUpdates == nil {
 		allowedUpdates = []string{}
@@ -164,14 +434,33 @@ func (u Updater) SetWebhook(path string, webhook Webhook) (bool, error) {
 
 	v := url.Values{}
 	v.Add("url", webhook.URL+"/"+path)
-	//v.Add("certificate", ) // todo: add certificate support
 	v.Add("max_connections", strconv.Itoa(webhook.MaxConnections))
 	v.Add("allowed_updates", string(allowed))
+	if webhook.SecretToken != "" {
+		v.Add("secret_token", webhook.SecretToken)
+	}
+	if webhook.DropPending {
+		v.Add("drop_pending_updates", strconv.FormatBool(true))
+	}
 
-	r, err := ext.Get(*u.Bot, "setWebhook", v)
+	var r *ext.Response
+	if webhook.UploadCert {
+		cert, openErr := os.Open(webhook.CertPath)
+		if openErr != nil {
+			return false, errors.Wrap(openErr, "unable to open certificate")
+		}
+		defer cert.Close()
+		r, err = ext.Post(*u.Bot, "certificate", "setWebhook", v, cert, filepath.Base(webhook.CertPath))
+	} else {
+		r, err = ext.Get(*u.Bot, "setWebhook", v)
+	}
 	if err != nil {
 		return false, errors.Wrap(err, "failed to set webhook")
 	}
+	if !r.Ok {
+		return false, ext.NewTelegramError("setWebhook", r)
+	}
+	u.webhookSecret.Store(webhook.SecretToken)
 
 	var bb bool
 	json.Unmarshal(r.Result, &bb)
@@ -183,16 +472,19 @@ type WebhookInfo struct {
 	HasCustomCertificate bool     `json:"has_custom_certificate"`
 	PendingUpdateCount   int      `json:"pending_update_count"`
 	LastErrorDate        int      `json:"last_error_date"`
-	LastErrorMessage     int      `json:"last_error_message"`
+	LastErrorMessage     string   `json:"last_error_message"`
 	MaxConnections       int      `json:"max_connections"`
 	AllowedUpdates       []string `json:"allowed_updates"`
 }
 
-func (u Updater) GetWebhookInfo() (*WebhookInfo, error) {
+func (u *Updater) GetWebhookInfo() (*WebhookInfo, error) {
 	r, err := ext.Get(*u.Bot, "getWebhookInfo", nil)
 	if err != nil {
 		return nil, err
 	}
+	if !r.Ok {
+		return nil, ext.NewTelegramError("getWebhookInfo", r)
+	}
 
 	var wh WebhookInfo
 	json.Unmarshal(r.Result, &wh)
@@ -0,0 +1,36 @@
+package gotgbot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	opts := PollingOptions{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for _, tc := range []struct {
+		failures int
+		max      time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	} {
+		for i := 0; i < 20; i++ {
+			if d := opts.backoff(tc.failures); d < tc.max/2 || d > tc.max {
+				t.Fatalf("backoff after %d failures was %v, expected between %v and %v", tc.failures, d, tc.max/2, tc.max)
+			}
+		}
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	if d := (PollingOptions{}).backoff(100); d > DefaultPollingOptions.MaxBackoff || d < DefaultPollingOptions.MaxBackoff/2 {
+		t.Fatalf("backoff without options was %v", d)
+	}
+	// a maximum below the minimum is raised to it.
+	if d := (PollingOptions{MinBackoff: time.Minute, MaxBackoff: time.Second}).backoff(3); d < 30*time.Second || d > time.Minute {
+		t.Fatalf("backoff with a maximum below the minimum was %v", d)
+	}
+}
@@ -0,0 +1,266 @@
+package gotgbot_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/gotgbottest"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+)
+
+var testUser = gotgbottest.User{Id: 42, FirstName: "Ann"}
+
+func newTestUpdater(t *testing.T) (*gotgbottest.Server, *gotgbot.Updater) {
+	t.Helper()
+	srv := gotgbottest.NewServer()
+	t.Cleanup(srv.Close)
+	u, err := srv.NewUpdater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, u
+}
+
+// blockingCommand returns a handler for /block which signals when it starts, and blocks until release is closed or
+// the update's context is done.
+func blockingCommand(started chan<- struct{}, release <-chan struct{}, finished *int32) handlers.Command {
+	return handlers.NewCommand("block", func(b ext.Bot, u *gotgbot.Update) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-u.Context().Done():
+		}
+		atomic.AddInt32(finished, 1)
+		return nil
+	})
+}
+
+func TestStopDrainsInFlightUpdates(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	var finished int32
+	u.Dispatcher.AddHandler(blockingCommand(started, release, &finished))
+	u.StartPolling()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/block")
+	<-started
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Fatal("Stop returned before the in-flight update was handled")
+	}
+}
+
+func TestShutdownTimeoutCancelsInFlightUpdates(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	started := make(chan struct{}, 1)
+	var finished int32
+	u.Dispatcher.AddHandler(blockingCommand(started, nil, &finished))
+	u.StartPolling()
+
+	srv.SendMessage(srv.PrivateChat(testUser), testUser, "/block")
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := u.Shutdown(ctx); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	// the handler's context is cancelled, so the dispatcher can still finish draining.
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Fatal("in-flight update was not finished")
+	}
+}
+
+func TestShutdownClosesStuckWebhookServer(t *testing.T) {
+	_, u := newTestUpdater(t)
+	port := freePort(t)
+	u.StartWebhook(gotgbot.Webhook{Serve: "127.0.0.1", ServePort: port, ServePath: "hook"})
+
+	// a request whose body never arrives keeps the server from shutting down gracefully.
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "POST /hook HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: 100\r\n\r\n{")
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := u.Shutdown(ctx); err == nil {
+		t.Fatal("expected the webhook server to fail to shut down in time")
+	}
+	// the server was closed and the updates channel with it, so the dispatcher still drains.
+	done := make(chan error, 1)
+	go func() { done <- u.Stop() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("dispatcher was never drained")
+	}
+	if _, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d/hook", port), "application/json", nil); err == nil {
+		t.Fatal("webhook server is still running")
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestUpdatersUseTheirOwnRequester(t *testing.T) {
+	srvA, a := newTestUpdater(t)
+	srvB, b := newTestUpdater(t)
+	for _, u := range []*gotgbot.Updater{a, b} {
+		u.Dispatcher.AddHandler(handlers.NewCommand("ping", func(b ext.Bot, u *gotgbot.Update) error {
+			_, err := u.EffectiveMessage.ReplyText("pong")
+			return err
+		}))
+		u.StartPolling()
+		defer u.Stop()
+	}
+
+	srvA.SendMessage(srvA.PrivateChat(testUser), testUser, "/ping")
+	if _, ok := srvA.WaitForCall("sendMessage", 2*time.Second); !ok {
+		t.Fatal("first bot didn't reply through its own server")
+	}
+	if len(srvB.CallsTo("sendMessage")) != 0 {
+		t.Fatal("first bot replied through the second bot's server")
+	}
+	srvB.SendMessage(srvB.PrivateChat(testUser), testUser, "/ping")
+	if _, ok := srvB.WaitForCall("sendMessage", 2*time.Second); !ok {
+		t.Fatal("second bot didn't reply through its own server")
+	}
+	if len(srvA.CallsTo("sendMessage")) != 1 {
+		t.Fatal("second bot replied through the first bot's server")
+	}
+}
+
+func TestPollingOptionsAreSent(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	u.StartPollingWithOptions(gotgbot.PollingOptions{
+		Timeout:        20 * time.Second,
+		Limit:          5,
+		AllowedUpdates: []string{"message"},
+	})
+	defer u.Stop()
+
+	c, ok := srv.WaitForCall("getUpdates", 2*time.Second)
+	if !ok {
+		t.Fatal("bot never polled")
+	}
+	if c.Params.Get("timeout") != "20" || c.Params.Get("limit") != "5" || c.Params.Get("allowed_updates") != `["message"]` {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+}
+
+func TestPollingBacksOffAfterFailures(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	var mu sync.Mutex
+	var polls []time.Time
+	srv.Handle("getUpdates", func(c gotgbottest.Call) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls = append(polls, time.Now())
+		if len(polls) <= 3 {
+			return nil, &ext.TelegramError{Code: http.StatusBadGateway, Description: "Bad Gateway"}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return []interface{}{}, nil
+	})
+	u.StartPollingWithOptions(gotgbot.PollingOptions{MinBackoff: 20 * time.Millisecond, MaxBackoff: 40 * time.Millisecond})
+	defer u.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(polls)
+		mu.Unlock()
+		if n >= 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("polling stopped after %d requests", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// each wait is between half and all of the backoff, which doubles from 20ms up to 40ms.
+	for i, min := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond} {
+		if wait := polls[i+1].Sub(polls[i]); wait < min || wait > time.Second {
+			t.Errorf("wait after failure %d was %v, expected at least %v", i+1, wait, min)
+		}
+	}
+}
+
+func TestStartCleanPollingDropsPendingUpdates(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	h, texts := textReceiver()
+	u.Dispatcher.AddHandler(h)
+	chat := srv.PrivateChat(testUser)
+	for i := 0; i < 3; i++ {
+		srv.SendMessage(chat, testUser, "old")
+	}
+	if err := u.StartCleanPolling(); err != nil {
+		t.Fatal(err)
+	}
+	defer u.Stop()
+	calls := srv.CallsTo("deleteWebhook")
+	if c := calls[len(calls)-1]; c.Params.Get("drop_pending_updates") != "true" {
+		t.Fatalf("pending updates weren't dropped: %+v", calls)
+	}
+
+	srv.SendMessage(chat, testUser, "new")
+	expectText(t, texts, "new")
+}
+
+func TestDropPendingUpdatesCountsThem(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	chat := srv.PrivateChat(testUser)
+	for i := 0; i < 3; i++ {
+		srv.SendMessage(chat, testUser, "old")
+	}
+	dropped, err := u.DropPendingUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 3 {
+		t.Fatalf("expected 3 dropped updates, got %d", dropped)
+	}
+	if dropped, _ := u.DropPendingUpdates(); dropped != 0 {
+		t.Fatalf("expected nothing left to drop, got %d", dropped)
+	}
+}
@@ -0,0 +1,105 @@
+package gotgbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// secretTokenHeader is the header telegram sends the webhook's secret token in.
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// maxWebhookBodySize limits the size of a single update received through the webhook.
+	maxWebhookBodySize = 1 << 20
+)
+
+// WebhookHandler returns a handler which passes the updates telegram sends to the webhook on to the dispatcher, and
+// starts the dispatcher. It can be mounted on any path of an existing server, so that several bots can share one
+// server. Requests which don't include the secret token set by SetWebhook or SetWebhookSecret are rejected; without
+// a secret token, all requests are accepted, so anyone who finds the path can send the bot fake updates.
+// Stopping the updater doesn't stop the server; any updates received after it has stopped are rejected, so that
+// telegram sends them again later.
+func (u *Updater) WebhookHandler() http.Handler {
+	u.startDispatcher()
+	return u.webhookHandler("", func() string {
+		secret, _ := u.webhookSecret.Load().(string)
+		return secret
+	})
+}
+
+// SetWebhookSecret sets the secret token WebhookHandler expects, eg when the webhook was set by another process.
+// SetWebhook sets it as well.
+func (u *Updater) SetWebhookSecret(secret string) {
+	u.webhookSecret.Store(secret)
+}
+
+// webhookHandler returns a handler which queues the updates telegram sends to the webhook. Requests to any other path
+// than the given one are rejected, unless path is empty. If secretToken returns a token, requests which don't include
+// it are rejected.
+func (u *Updater) webhookHandler(path string, secretToken func() string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path != "" && r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if secret := secretToken(); secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secret)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			http.Error(w, "expected a JSON body", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			http.Error(w, "unable to read body", http.StatusBadRequest)
+			return
+		}
+		if !json.Valid(body) {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+
+		upd := RawUpdate(body)
+		if err := u.queueUpdate(r.Context(), &upd); err != nil {
+			// anything but a 200 makes telegram send the update again later.
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// queueUpdate passes the update on to the dispatcher. It fails if ctx is done, or the updater is stopped, before the
+// dispatcher has accepted the update.
+func (u *Updater) queueUpdate(ctx context.Context, upd *RawUpdate) error {
+	// the updates channel is closed on shutdown while holding the write lock, so it is safe to send while holding
+	// the read lock, as long as the updater hasn't been stopped.
+	u.sendMu.RLock()
+	defer u.sendMu.RUnlock()
+	select {
+	case <-u.ctx.Done():
+		return errors.New("updater is stopping")
+	default:
+	}
+
+	select {
+	case u.updates <- upd:
+		return nil
+	case <-u.ctx.Done():
+		return errors.New("updater is stopping")
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "unable to queue update")
+	}
+}
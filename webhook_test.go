@@ -0,0 +1,246 @@
+package gotgbot_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot"
+	"github.com/PaulSonOfLars/gotgbot/ext"
+	"github.com/PaulSonOfLars/gotgbot/handlers"
+	"github.com/PaulSonOfLars/gotgbot/handlers/Filters"
+)
+
+const webhookUpdate = `{"update_id":1,"message":{"message_id":1,"text":"hi","chat":{"id":42,"type":"private"},"from":{"id":42,"first_name":"Ann"}}}`
+
+// writeCert writes a self-signed certificate for 127.0.0.1, and its key.
+func writeCert(t *testing.T) (certPath string, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+// textReceiver returns a handler which passes on the text of every message it handles.
+func textReceiver() (handlers.Message, <-chan string) {
+	texts := make(chan string, 10)
+	return handlers.NewMessage(Filters.All, func(b ext.Bot, u *gotgbot.Update) error {
+		texts <- u.EffectiveMessage.Text
+		return nil
+	}), texts
+}
+
+func expectText(t *testing.T, texts <-chan string, want string) {
+	t.Helper()
+	select {
+	case text := <-texts:
+		if text != want {
+			t.Fatalf("expected %q, got %q", want, text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("update was never handled")
+	}
+}
+
+// postUpdate sends an update to a webhook, and returns the response's status code.
+func postUpdate(t *testing.T, client *http.Client, method string, url string, contentType string, secret string, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	return resp.StatusCode
+}
+
+func TestSetWebhookUploadsCertificate(t *testing.T) {
+	srv, u := newTestUpdater(t)
+	certPath, keyPath := writeCert(t)
+	wh := gotgbot.Webhook{URL: "https://example.com", CertPath: certPath, KeyPath: keyPath, UploadCert: true, SecretToken: "s3cret"}
+	if _, err := u.SetWebhook("hook", wh); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.CallsTo("setWebhook")
+	if len(calls) != 1 {
+		t.Fatalf("expected a single setWebhook call, got %d", len(calls))
+	}
+	c := calls[0]
+	if c.File == nil || c.File.Field != "certificate" || !strings.Contains(string(c.File.Data), "BEGIN CERTIFICATE") {
+		t.Fatalf("certificate wasn't uploaded: %+v", c.File)
+	}
+	if c.Params.Get("url") != "https://example.com/hook" || c.Params.Get("secret_token") != "s3cret" {
+		t.Fatalf("unexpected params %v", c.Params)
+	}
+}
+
+func TestWebhookServerValidatesRequests(t *testing.T) {
+	_, u := newTestUpdater(t)
+	h, texts := textReceiver()
+	u.Dispatcher.AddHandler(h)
+	certPath, keyPath := writeCert(t)
+	port := freePort(t)
+	u.StartWebhook(gotgbot.Webhook{
+		Serve:       "127.0.0.1",
+		ServePort:   port,
+		ServePath:   "hook",
+		CertPath:    certPath,
+		KeyPath:     keyPath,
+		SecretToken: "s3cret",
+	})
+	defer u.Stop()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	// the server waits for connections which haven't sent a request yet before shutting down.
+	defer client.CloseIdleConnections()
+	base := fmt.Sprintf("https://127.0.0.1:%d", port)
+	waitForServer(t, client, base)
+	for _, tc := range []struct {
+		method, path, contentType, secret, body string
+		code                                    int
+	}{
+		{"GET", "/hook", "application/json", "s3cret", "", http.StatusMethodNotAllowed},
+		{"POST", "/other", "application/json", "s3cret", webhookUpdate, http.StatusNotFound},
+		{"POST", "/hook", "application/json", "", webhookUpdate, http.StatusUnauthorized},
+		{"POST", "/hook", "application/json", "wrong", webhookUpdate, http.StatusUnauthorized},
+		{"POST", "/hook", "text/plain", "s3cret", webhookUpdate, http.StatusUnsupportedMediaType},
+		{"POST", "/hook", "application/json", "s3cret", "{nope", http.StatusBadRequest},
+		{"POST", "/hook", "application/json; charset=utf-8", "s3cret", webhookUpdate, http.StatusOK},
+	} {
+		if code := postUpdate(t, client, tc.method, base+tc.path, tc.contentType, tc.secret, tc.body); code != tc.code {
+			t.Fatalf("%s %s with %q and secret %q: expected %d, got %d", tc.method, tc.path, tc.contentType, tc.secret, tc.code, code)
+		}
+	}
+	// only the valid request was passed on.
+	expectText(t, texts, "hi")
+	select {
+	case text := <-texts:
+		t.Fatalf("unexpected update %q", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// waitForServer waits until the server is accepting connections.
+func waitForServer(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server never started")
+}
+
+func TestWebhookHandlersShareAServer(t *testing.T) {
+	mux := http.NewServeMux()
+	var received []<-chan string
+	var updaters []*gotgbot.Updater
+	for _, name := range []string{"a", "b"} {
+		_, u := newTestUpdater(t)
+		h, texts := textReceiver()
+		u.Dispatcher.AddHandler(h)
+		if _, err := u.SetWebhook(name, gotgbot.Webhook{URL: "https://example.com", SecretToken: "token " + name}); err != nil {
+			t.Fatal(err)
+		}
+		mux.Handle("/"+name, u.WebhookHandler())
+		received = append(received, texts)
+		updaters = append(updaters, u)
+	}
+	hs := httptest.NewServer(mux)
+	defer hs.Close()
+
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL+"/a", "application/json", "token b", webhookUpdate); code != http.StatusUnauthorized {
+		t.Fatalf("another bot's token was accepted: %d", code)
+	}
+	for i, name := range []string{"a", "b"} {
+		if code := postUpdate(t, hs.Client(), "POST", hs.URL+"/"+name, "application/json", "token "+name, webhookUpdate); code != http.StatusOK {
+			t.Fatalf("bot %s: expected 200, got %d", name, code)
+		}
+		expectText(t, received[i], "hi")
+	}
+
+	// a stopped updater rejects updates, so that telegram sends them again later.
+	for _, u := range updaters {
+		if err := u.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL+"/a", "application/json", "token a", webhookUpdate); code != http.StatusServiceUnavailable {
+		t.Fatalf("stopped updater accepted an update: %d", code)
+	}
+}
+
+func TestWebhookHandlerSecret(t *testing.T) {
+	_, u := newTestUpdater(t)
+	h, texts := textReceiver()
+	u.Dispatcher.AddHandler(h)
+	hs := httptest.NewServer(u.WebhookHandler())
+	defer hs.Close()
+	defer u.Stop()
+
+	// without a secret, anything goes.
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL, "application/json", "", webhookUpdate); code != http.StatusOK {
+		t.Fatalf("expected 200 without a secret, got %d", code)
+	}
+	expectText(t, texts, "hi")
+
+	// the webhook was set elsewhere, so the secret is set directly.
+	u.SetWebhookSecret("s3cret")
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL, "application/json", "", webhookUpdate); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the secret, got %d", code)
+	}
+	if code := postUpdate(t, hs.Client(), "POST", hs.URL, "application/json", "s3cret", webhookUpdate); code != http.StatusOK {
+		t.Fatalf("expected 200 with the secret, got %d", code)
+	}
+	expectText(t, texts, "hi")
+}